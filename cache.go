@@ -0,0 +1,307 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const cacheManifestName = ".cache.json"
+
+type cacheEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	Hash    string    `json:"hash"`
+
+	Output        string    `json:"output"`
+	OutputSize    int64     `json:"outputSize"`
+	OutputModTime time.Time `json:"outputModTime"`
+	OutputHash    string    `json:"outputHash"`
+}
+
+// cacheManifest is stored in the decrypt directory and records every decrypted file,
+// files that did not change since the last run are not decrypted again
+type cacheManifest struct {
+	path string
+	mx   *sync.Mutex
+	seen map[string]struct{}
+	// reset entries are never unchanged
+	reset bool
+
+	Files map[string]cacheEntry `json:"files"`
+	// ParsedInputs is a digest of all inputs at the time of the last successful parse
+	ParsedInputs string `json:"parsedInputs"`
+}
+
+func loadCacheManifest(dir string) (*cacheManifest, error) {
+	manifest := &cacheManifest{
+		path:  filepath.Join(dir, cacheManifestName),
+		mx:    &sync.Mutex{},
+		seen:  make(map[string]struct{}),
+		Files: make(map[string]cacheEntry),
+	}
+
+	data, err := os.ReadFile(manifest.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read cache manifest")
+	}
+
+	err = json.Unmarshal(data, manifest)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode cache manifest")
+	}
+	if manifest.Files == nil {
+		manifest.Files = make(map[string]cacheEntry)
+	}
+	return manifest, nil
+}
+
+// Unchanged checks if a source file was already decrypted into an output that still exists and was not modified.
+// When only the modification time of the source changed, the file is hashed and the entry is updated.
+func (m *cacheManifest) Unchanged(key, sourcePath, outputPath string) (bool, error) {
+	m.mx.Lock()
+	entry, ok := m.Files[key]
+	m.seen[key] = struct{}{}
+	reset := m.reset
+	m.mx.Unlock()
+	if !ok || reset || entry.Output != outputPath {
+		return false, nil
+	}
+
+	output, err := os.Stat(outputPath)
+	if err != nil || output.Size() != entry.OutputSize {
+		return false, nil
+	}
+	if !output.ModTime().Equal(entry.OutputModTime) {
+		// the output was touched after it was written, it is only hashed in this case
+		outputHash, err := hashFile(outputPath)
+		if err != nil || outputHash != entry.OutputHash {
+			return false, nil
+		}
+	}
+
+	source, err := os.Stat(sourcePath)
+	if err != nil {
+		return false, err
+	}
+	if source.Size() != entry.Size {
+		return false, nil
+	}
+	if !source.ModTime().Equal(entry.ModTime) {
+		hash, err := hashFile(sourcePath)
+		if err != nil {
+			return false, err
+		}
+		if hash != entry.Hash {
+			return false, nil
+		}
+	}
+
+	entry.ModTime = source.ModTime()
+	entry.OutputModTime = output.ModTime()
+	m.mx.Lock()
+	m.Files[key] = entry
+	m.mx.Unlock()
+	return true, nil
+}
+
+// Record saves a decrypted file, output hash is the hash of decrypted data
+func (m *cacheManifest) Record(key, sourcePath, outputPath string, outputSize int64, outputHash string) error {
+	source, err := os.Stat(sourcePath)
+	if err != nil {
+		return err
+	}
+	hash, err := hashFile(sourcePath)
+	if err != nil {
+		return err
+	}
+	output, err := os.Stat(outputPath)
+	if err != nil {
+		return err
+	}
+
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	m.seen[key] = struct{}{}
+	m.Files[key] = cacheEntry{
+		Size:          source.Size(),
+		ModTime:       source.ModTime(),
+		Hash:          hash,
+		Output:        outputPath,
+		OutputSize:    outputSize,
+		OutputModTime: output.ModTime(),
+		OutputHash:    outputHash,
+	}
+	return nil
+}
+
+// Reset makes every file and the parse stage run again, entries are kept until Prune so their outputs can still be removed
+func (m *cacheManifest) Reset() {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	m.reset = true
+	m.ParsedInputs = ""
+}
+
+// Prune removes entries for files that were not seen since the manifest was loaded, together with their outputs.
+// A file that is no longer selected by rules or was removed from the dump would otherwise still be parsed.
+func (m *cacheManifest) Prune() error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	outputs := make(map[string]struct{})
+	for key, entry := range m.Files {
+		if _, ok := m.seen[key]; ok {
+			outputs[entry.Output] = struct{}{}
+		}
+	}
+
+	var errs []error
+	for key, entry := range m.Files {
+		if _, ok := m.seen[key]; ok {
+			continue
+		}
+		delete(m.Files, key)
+
+		// another source can be decrypted into the same output, for example a passthrough copy of a dvpl file
+		if _, ok := outputs[entry.Output]; ok {
+			continue
+		}
+		err := os.Remove(entry.Output)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, errors.Wrap(err, "failed to remove a stale output"))
+		}
+	}
+	return joinErrors(errs...)
+}
+
+// InputDigest returns a hash of all files in fsys.
+// When fsys is the decrypt directory at outputsRoot, decrypted files that were not modified since they were written
+// use hashes from the manifest instead of being read.
+func (m *cacheManifest) InputDigest(fsys fs.FS, outputsRoot string) (string, error) {
+	known := make(map[string]cacheEntry)
+	if outputsRoot != "" {
+		m.mx.Lock()
+		for _, entry := range m.Files {
+			rel, err := filepath.Rel(outputsRoot, entry.Output)
+			if err == nil {
+				known[filepath.ToSlash(rel)] = entry
+			}
+		}
+		m.mx.Unlock()
+	}
+
+	hashes := make(map[string]string)
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path == cacheManifestName {
+			return nil
+		}
+
+		if entry, ok := known[path]; ok {
+			info, err := d.Info()
+			if err == nil && info.Size() == entry.OutputSize && info.ModTime().Equal(entry.OutputModTime) {
+				hashes[path] = entry.OutputHash
+				return nil
+			}
+		}
+
+		f, err := fsys.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		hashes[path], err = hashReader(f)
+		return err
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to hash parse inputs")
+	}
+
+	var paths []string
+	for path := range hashes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	digest := sha256.New()
+	for _, path := range paths {
+		digest.Write([]byte(path + "\x00" + hashes[path] + "\n"))
+	}
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+func (m *cacheManifest) Save() error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	err := os.MkdirAll(filepath.Dir(m.path), os.ModePerm)
+	if err != nil {
+		return errors.Wrap(err, "failed to create path")
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.path, data, os.ModePerm)
+}
+
+// buildVersion identifies the running binary, it is the vcs revision of a clean build or a hash of the executable
+func buildVersion() (string, error) {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		var revision, modified string
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				revision = setting.Value
+			case "vcs.modified":
+				modified = setting.Value
+			}
+		}
+		if revision != "" && modified == "false" {
+			return revision, nil
+		}
+	}
+
+	path, err := os.Executable()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to find the executable")
+	}
+	return hashFile(path)
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return hashReader(f)
+}
+
+func hashReader(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cufee/aftermath-assets/dvpl"
+	"github.com/matryer/is"
+)
+
+func TestDecryptCache(t *testing.T) {
+	is := is.New(t)
+
+	dump := t.TempDir()
+	out := t.TempDir()
+
+	writeDVPL := func(name, content string) {
+		encoded, err := dvpl.Encode([]byte(content), dvpl.CompressionLZ4)
+		is.NoErr(err)
+		is.NoErr(os.MkdirAll(filepath.Dir(filepath.Join(dump, name)), os.ModePerm))
+		is.NoErr(os.WriteFile(filepath.Join(dump, name+".dvpl"), encoded, os.ModePerm))
+	}
+	writeDVPL("Strings/en.yaml", "battleType/regular: Regular Battle\n")
+	writeDVPL("version.txt", "11.1.0.743_4002766 release/11.1.0 WOTB_Win7")
+
+	cache, err := loadCacheManifest(out)
+	is.NoErr(err)
+	d := &decrypter{pool: newWorkerPool(2), cache: cache, rulesRoot: dump}
+	is.NoErr(d.decryptDir(context.Background(), dump, out))
+	is.Equal(len(cache.Files), 2)
+	is.Equal(d.stats.Decrypted.Load(), int64(2))
+	is.NoErr(cache.Save())
+
+	digest, err := cache.InputDigest(os.DirFS(out), out)
+	is.NoErr(err)
+
+	// a touched file with the same content is not decrypted again
+	source := filepath.Join(dump, "version.txt.dvpl")
+	is.NoErr(os.Chtimes(source, time.Now(), time.Now().Add(time.Hour)))
+
+	cache, err = loadCacheManifest(out)
+	is.NoErr(err)
+	unchanged, err := cache.Unchanged(source, source, filepath.Join(out, "version.txt"))
+	is.NoErr(err)
+	is.True(unchanged)
+
+	sameDigest, err := cache.InputDigest(os.DirFS(out), out)
+	is.NoErr(err)
+	is.Equal(digest, sameDigest)
+
+	// changed content is decrypted and changes the digest
+	writeDVPL("version.txt", "11.2.0.100_5000000 release/11.2.0 WOTB_Win7")
+	d = &decrypter{pool: newWorkerPool(2), cache: cache, rulesRoot: dump}
+	is.NoErr(d.decryptDir(context.Background(), dump, out))
+	is.Equal(d.stats.Decrypted.Load(), int64(1))
+	is.Equal(d.stats.Unchanged.Load(), int64(1))
+
+	newDigest, err := cache.InputDigest(os.DirFS(out), out)
+	is.NoErr(err)
+	is.True(digest != newDigest)
+
+	data, err := os.ReadFile(filepath.Join(out, "version.txt"))
+	is.NoErr(err)
+	is.Equal(string(data), "11.2.0.100_5000000 release/11.2.0 WOTB_Win7")
+
+	// an output edited in place is decrypted again, even when its size did not change
+	is.NoErr(os.WriteFile(filepath.Join(out, "version.txt"), []byte("11.2.0.100_5000000 release/11.2.0 WOTB_Win8"), os.ModePerm))
+	unchanged, err = cache.Unchanged(source, source, filepath.Join(out, "version.txt"))
+	is.NoErr(err)
+	is.True(!unchanged)
+
+	editedDigest, err := cache.InputDigest(os.DirFS(out), out)
+	is.NoErr(err)
+	is.True(editedDigest != newDigest)
+}
+
+func TestDecryptCachePrune(t *testing.T) {
+	is := is.New(t)
+
+	dump := t.TempDir()
+	out := t.TempDir()
+	for _, name := range []string{"Strings/en.yaml", "version.txt"} {
+		encoded, err := dvpl.Encode([]byte(name), dvpl.CompressionNone)
+		is.NoErr(err)
+		is.NoErr(os.MkdirAll(filepath.Dir(filepath.Join(dump, name)), os.ModePerm))
+		is.NoErr(os.WriteFile(filepath.Join(dump, name+".dvpl"), encoded, os.ModePerm))
+	}
+
+	cache, err := loadCacheManifest(out)
+	is.NoErr(err)
+	d := &decrypter{pool: newWorkerPool(2), cache: cache, rulesRoot: dump}
+	is.NoErr(d.decryptDir(context.Background(), dump, out))
+	is.NoErr(cache.Prune())
+	is.NoErr(cache.Save())
+
+	// outputs of files excluded by rules are removed with their entries
+	rules, err := newFileRules(defaultDecryptRules, []string{"version.txt.dvpl"})
+	is.NoErr(err)
+	cache, err = loadCacheManifest(out)
+	is.NoErr(err)
+	cache.Reset()
+	d = &decrypter{pool: newWorkerPool(2), cache: cache, rules: rules, rulesRoot: dump}
+	is.NoErr(d.decryptDir(context.Background(), dump, out))
+	is.Equal(d.stats.Decrypted.Load(), int64(1)) // a reset cache decrypts every selected file
+	is.NoErr(cache.Prune())
+
+	is.Equal(len(cache.Files), 1)
+	_, err = os.Stat(filepath.Join(out, "version.txt"))
+	is.True(errors.Is(err, fs.ErrNotExist))
+	_, err = os.Stat(filepath.Join(out, "Strings", "en.yaml"))
+	is.NoErr(err)
+}
+
+func TestParseDigest(t *testing.T) {
+	is := is.New(t)
+
+	plugins := filepath.Join(t.TempDir(), "plugins.yaml")
+	is.NoErr(os.WriteFile(plugins, []byte("[]"), os.ModePerm))
+	config := testParseConfig(t)
+	config.configs = []string{"", plugins}
+
+	digest, err := parseDigest("inputs", []string{"maps"}, config)
+	is.NoErr(err)
+	same, err := parseDigest("inputs", []string{"maps"}, config)
+	is.NoErr(err)
+	is.Equal(digest, same)
+
+	// configs and the output path are a part of the digest
+	is.NoErr(os.WriteFile(plugins, []byte("[] "), os.ModePerm))
+	changed, err := parseDigest("inputs", []string{"maps"}, config)
+	is.NoErr(err)
+	is.True(changed != digest)
+
+	config.assetsPath = t.TempDir()
+	moved, err := parseDigest("inputs", []string{"maps"}, config)
+	is.NoErr(err)
+	is.True(moved != changed)
+
+	// parsing is not skipped when exported files were removed
+	is.True(!exportsExist([]string{"maps"}, config))
+	is.NoErr(os.WriteFile(filepath.Join(config.assetsPath, "maps.json"), []byte("{}"), os.ModePerm))
+	is.True(exportsExist([]string{"maps"}, config))
+}
+
+func TestParseDumpDigest(t *testing.T) {
+	is := is.New(t)
+
+	dump := t.TempDir()
+	writeDVPL := func(name, content string) {
+		encoded, err := dvpl.Encode([]byte(content), dvpl.CompressionLZ4)
+		is.NoErr(err)
+		is.NoErr(os.MkdirAll(filepath.Dir(filepath.Join(dump, name)), os.ModePerm))
+		is.NoErr(os.WriteFile(filepath.Join(dump, name+".dvpl"), encoded, os.ModePerm))
+	}
+	writeDVPL("version.txt", "11.1.0.743_4002766 release/11.1.0 WOTB_Win7")
+	writeDVPL("3d/tank.sc2", "binary")
+
+	rules, err := newFileRules(defaultDecryptRules, nil)
+	is.NoErr(err)
+	selected := newFilterFS(os.DirFS(dump), func(path string) bool {
+		return rules.Selected(path, false)
+	})
+	cache, err := loadCacheManifest(t.TempDir())
+	is.NoErr(err)
+
+	digest, err := cache.InputDigest(selected, "")
+	is.NoErr(err)
+
+	// files which are not selected by rules are not a part of the digest
+	writeDVPL("3d/tank.sc2", "changed binary")
+	sameDigest, err := cache.InputDigest(selected, "")
+	is.NoErr(err)
+	is.Equal(digest, sameDigest)
+
+	writeDVPL("version.txt", "11.2.0.100_5000000 release/11.2.0 WOTB_Win7")
+	newDigest, err := cache.InputDigest(selected, "")
+	is.NoErr(err)
+	is.True(digest != newDigest)
+
+	_, err = fs.Stat(dvpl.NewFS(selected), "3d/tank.sc2")
+	is.True(errors.Is(err, fs.ErrNotExist))
+}
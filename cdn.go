@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
-	"time"
 
 	"golang.org/x/text/language"
 )
@@ -27,7 +26,7 @@ type vehicleRecord struct {
 	ID   int    `json:"tank_id"`
 }
 
-func (c *wargamingCDNClient) Vehicles(locales ...string) (map[string]map[language.Tag]vehicleRecord, error) {
+func (c *wargamingCDNClient) Vehicles(ctx context.Context, locales ...string) (map[string]map[language.Tag]vehicleRecord, error) {
 	if c.applicationID == "" {
 		return nil, errors.New("missing application id")
 	}
@@ -40,23 +39,20 @@ func (c *wargamingCDNClient) Vehicles(locales ...string) (map[string]map[languag
 
 	var wg sync.WaitGroup
 	var glossaryLock sync.Mutex
-	errorCh := make(chan error)
-
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*45)
-	defer cancel()
+	errorCh := make(chan error, len(locales))
 
 	for _, l := range locales {
 		wg.Add(1)
 		go func(locale string) {
 			defer wg.Done()
 
-			req, err := http.NewRequest("GET", "https://api.wotblitz.eu/wotb/encyclopedia/vehicles/?fields=name%2Ctank_id&application_id="+c.applicationID, nil)
+			req, err := http.NewRequestWithContext(ctx, "GET", "https://api.wotblitz.eu/wotb/encyclopedia/vehicles/?fields=name%2Ctank_id&application_id="+c.applicationID, nil)
 			if err != nil {
 				errorCh <- err
 				return
 			}
 
-			res, err := http.DefaultClient.Do(req.WithContext(ctx))
+			res, err := http.DefaultClient.Do(req)
 			if err != nil {
 				errorCh <- err
 				return
@@ -107,7 +103,7 @@ func (c *wargamingCDNClient) Vehicles(locales ...string) (map[string]map[languag
 	return glossary, nil
 }
 
-func (c *wargamingCDNClient) MissingStrings(locales ...string) (map[language.Tag]map[string]string, error) {
+func (c *wargamingCDNClient) MissingStrings(ctx context.Context, locales ...string) (map[language.Tag]map[string]string, error) {
 	if len(locales) == 0 {
 		locales = append(locales, "en")
 	}
@@ -116,22 +112,19 @@ func (c *wargamingCDNClient) MissingStrings(locales ...string) (map[language.Tag
 
 	var lock sync.Mutex
 	var wg sync.WaitGroup
-	errorCh := make(chan error)
-
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
-	defer cancel()
+	errorCh := make(chan error, len(locales))
 
 	for _, l := range locales {
 		wg.Add(1)
 		go func(locale string) {
 			defer wg.Done()
-			req, err := http.NewRequest("GET", fmt.Sprintf("https://stufficons.wgcdn.co/localizations/%v.yaml", locale), nil)
+			req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://stufficons.wgcdn.co/localizations/%v.yaml", locale), nil)
 			if err != nil {
 				errorCh <- err
 				return
 			}
 
-			res, err := http.DefaultClient.Do(req.WithContext(ctx))
+			res, err := http.DefaultClient.Do(req)
 			if err != nil {
 				errorCh <- err
 				return
@@ -139,7 +132,7 @@ func (c *wargamingCDNClient) MissingStrings(locales ...string) (map[language.Tag
 			defer res.Body.Close()
 
 			if res.StatusCode != 200 {
-				println("failed to get missing localization strings for "+locale, res.Status)
+				loggerFrom(ctx).Warn("failed to get missing localization strings", "locale", locale, "status", res.Status)
 				return
 			}
 
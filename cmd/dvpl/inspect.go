@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cufee/aftermath-assets/dvpl"
+	"github.com/pkg/errors"
+)
+
+type inspectCmd struct {
+	DumpPath string `arg:"positional,required" help:"path to the depot dump directory" placeholder:"<dump_path>"`
+	Format   string `arg:"--format" default:"table" help:"output format, table or json" placeholder:"<format>"`
+	Summary  bool   `help:"only print aggregate stats, without every file"`
+}
+
+type fileReport struct {
+	Path           string  `json:"path"`
+	Extension      string  `json:"extension"`
+	OriginalSize   uint32  `json:"originalSize"`
+	CompressedSize uint32  `json:"compressedSize"`
+	Compression    string  `json:"compression"`
+	Ratio          float64 `json:"ratio"`
+	CRCValid       bool    `json:"crcValid"`
+	Error          string  `json:"error,omitempty"`
+}
+
+type aggregate struct {
+	Files          int     `json:"files"`
+	OriginalSize   int64   `json:"originalSize"`
+	CompressedSize int64   `json:"compressedSize"`
+	Ratio          float64 `json:"ratio"`
+	Invalid        int     `json:"invalid"`
+}
+
+func (a *aggregate) add(file fileReport) {
+	a.Files++
+	a.OriginalSize += int64(file.OriginalSize)
+	a.CompressedSize += int64(file.CompressedSize)
+	if a.CompressedSize > 0 {
+		a.Ratio = float64(a.OriginalSize) / float64(a.CompressedSize)
+	}
+	if !file.CRCValid {
+		a.Invalid++
+	}
+}
+
+type inspectReport struct {
+	Files       []fileReport          `json:"files,omitempty"`
+	Total       aggregate             `json:"total"`
+	Directories map[string]*aggregate `json:"directories"`
+	Extensions  map[string]*aggregate `json:"extensions"`
+}
+
+func (c *inspectCmd) Run(w io.Writer) error {
+	if c.Format != "json" && c.Format != "table" {
+		return errors.New("invalid format " + c.Format)
+	}
+
+	report := inspectReport{
+		Directories: make(map[string]*aggregate),
+		Extensions:  make(map[string]*aggregate),
+	}
+
+	err := filepath.WalkDir(c.DumpPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.HasSuffix(path, ".dvpl") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(c.DumpPath, path)
+		if err != nil {
+			return err
+		}
+		file := inspectFile(path)
+		file.Path = filepath.ToSlash(rel)
+
+		report.Total.add(file)
+		for _, group := range []struct {
+			key  string
+			dict map[string]*aggregate
+		}{{filepath.ToSlash(filepath.Dir(rel)), report.Directories}, {file.Extension, report.Extensions}} {
+			if group.dict[group.key] == nil {
+				group.dict[group.key] = &aggregate{}
+			}
+			group.dict[group.key].add(file)
+		}
+
+		if !c.Summary {
+			report.Files = append(report.Files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to walk the dump directory")
+	}
+
+	if c.Format == "json" {
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(report)
+	}
+	return report.writeTable(w)
+}
+
+func inspectFile(path string) fileReport {
+	file := fileReport{Extension: filepath.Ext(strings.TrimSuffix(path, ".dvpl"))}
+
+	f, err := os.Open(path)
+	if err != nil {
+		file.Error = err.Error()
+		return file
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		file.Error = err.Error()
+		return file
+	}
+
+	footer, err := dvpl.ReadFooter(f, info.Size())
+	if err != nil {
+		file.Error = err.Error()
+		return file
+	}
+	file.OriginalSize = footer.OriginalSize
+	file.CompressedSize = footer.CompressedSize
+	file.Compression = footer.CompressionName()
+	file.Ratio = footer.Ratio()
+
+	if int64(footer.CompressedSize) != info.Size()-dvpl.FooterSize {
+		file.Error = dvpl.ErrSizeMismatch.Error()
+		return file
+	}
+	file.CRCValid, err = dvpl.VerifyCRC(f, footer)
+	if err != nil {
+		file.Error = err.Error()
+	}
+	return file
+}
+
+func (r inspectReport) writeTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if len(r.Files) > 0 {
+		fmt.Fprintln(tw, "path\text\toriginal\tcompressed\tcompression\tratio\tcrc\t")
+		for _, file := range r.Files {
+			crc := "ok"
+			if file.Error != "" {
+				crc = file.Error
+			} else if !file.CRCValid {
+				crc = "invalid"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%.2f\t%s\t\n", file.Path, file.Extension, file.OriginalSize, file.CompressedSize, file.Compression, file.Ratio, crc)
+		}
+		fmt.Fprintln(tw, "\t\t\t\t\t\t\t")
+	}
+
+	writeAggregates := func(title string, dict map[string]*aggregate) {
+		var keys []string
+		for key := range dict {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		fmt.Fprintf(tw, "%s\tfiles\toriginal\tcompressed\tratio\tinvalid\t\n", title)
+		for _, key := range keys {
+			a := dict[key]
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.2f\t%d\t\n", key, a.Files, a.OriginalSize, a.CompressedSize, a.Ratio, a.Invalid)
+		}
+		fmt.Fprintln(tw, "\t\t\t\t\t\t")
+	}
+	writeAggregates("directory", r.Directories)
+	writeAggregates("extension", r.Extensions)
+	writeAggregates("total", map[string]*aggregate{"all": &r.Total})
+
+	return tw.Flush()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cufee/aftermath-assets/dvpl"
+	"github.com/matryer/is"
+)
+
+func TestInspect(t *testing.T) {
+	is := is.New(t)
+
+	dump := t.TempDir()
+	writeDVPL := func(name string, content []byte, compression uint32, corrupt bool) {
+		encoded, err := dvpl.Encode(content, compression)
+		is.NoErr(err)
+		if corrupt {
+			encoded[0] ^= 0xff
+		}
+		is.NoErr(os.MkdirAll(filepath.Dir(filepath.Join(dump, name)), os.ModePerm))
+		is.NoErr(os.WriteFile(filepath.Join(dump, name), encoded, os.ModePerm))
+	}
+	writeDVPL("XML/list.xml.dvpl", bytes.Repeat([]byte("<root></root>"), 100), dvpl.CompressionLZ4, false)
+	writeDVPL("XML/broken.xml.dvpl", []byte("<root></root>"), dvpl.CompressionNone, true)
+	writeDVPL("Strings/en.yaml.dvpl", []byte("key: value"), dvpl.CompressionNone, false)
+	is.NoErr(os.WriteFile(filepath.Join(dump, "version.txt"), []byte("plain"), os.ModePerm))
+
+	var out bytes.Buffer
+	is.NoErr((&inspectCmd{DumpPath: dump, Format: "json"}).Run(&out))
+
+	var report inspectReport
+	is.NoErr(json.Unmarshal(out.Bytes(), &report))
+
+	// files which are not dvpl encoded are ignored
+	is.Equal(len(report.Files), 3)
+	is.Equal(report.Total.Files, 3)
+	is.Equal(report.Total.Invalid, 1)
+
+	files := make(map[string]fileReport)
+	for _, file := range report.Files {
+		files[file.Path] = file
+	}
+	is.Equal(files["XML/list.xml.dvpl"].Compression, "lz4")
+	is.Equal(files["XML/list.xml.dvpl"].OriginalSize, uint32(1300))
+	is.True(files["XML/list.xml.dvpl"].Ratio > 1)
+	is.True(files["XML/list.xml.dvpl"].CRCValid)
+	is.Equal(files["Strings/en.yaml.dvpl"].Compression, "none")
+	is.True(!files["XML/broken.xml.dvpl"].CRCValid)
+
+	is.Equal(len(report.Directories), 2)
+	is.Equal(report.Directories["XML"].Files, 2)
+	is.Equal(report.Directories["XML"].Invalid, 1)
+	is.Equal(report.Directories["Strings"].OriginalSize, int64(10))
+	is.Equal(len(report.Extensions), 2)
+	is.Equal(report.Extensions[".xml"].Files, 2)
+	is.Equal(report.Extensions[".yaml"].Files, 1)
+
+	// a summary has aggregates without files
+	out.Reset()
+	is.NoErr((&inspectCmd{DumpPath: dump, Format: "json", Summary: true}).Run(&out))
+	report = inspectReport{}
+	is.NoErr(json.Unmarshal(out.Bytes(), &report))
+	is.Equal(len(report.Files), 0)
+	is.Equal(report.Total.Files, 3)
+
+	out.Reset()
+	is.NoErr((&inspectCmd{DumpPath: dump, Format: "table"}).Run(&out))
+	is.True(strings.Contains(out.String(), "XML/broken.xml.dvpl"))
+	is.True(strings.Contains(out.String(), "invalid"))
+	is.True(strings.Contains(out.String(), ".yaml"))
+
+	// an invalid format fails before the dump is read
+	err := (&inspectCmd{DumpPath: filepath.Join(dump, "missing"), Format: "xml"}).Run(&out)
+	is.True(err != nil)
+	is.Equal(err.Error(), "invalid format xml")
+}
@@ -0,0 +1,31 @@
+// Command dvpl works with dvpl files from a World of Tanks Blitz depot dump
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/alexflint/go-arg"
+)
+
+var args struct {
+	Inspect *inspectCmd `arg:"subcommand:inspect" help:"print footer details and stats for every dvpl file"`
+}
+
+func main() {
+	p := arg.MustParse(&args)
+
+	var err error
+	switch {
+	case args.Inspect != nil:
+		err = args.Inspect.Run(os.Stdout)
+	default:
+		p.WriteHelp(os.Stdout)
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
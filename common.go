@@ -2,4 +2,4 @@ package main
 
 import "regexp"
 
-var stringsRegex = regexp.MustCompile(".*/Strings/.*.yaml")
+var stringsRegex = regexp.MustCompile("(^|/)Strings/.*.yaml")
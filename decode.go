@@ -2,7 +2,10 @@ package main
 
 import (
 	"encoding/json"
+	"encoding/xml"
 	"io"
+	"strconv"
+	"strings"
 
 	"github.com/clbanning/mxj"
 	"github.com/pkg/errors"
@@ -36,3 +39,88 @@ func decodeXML[T any](r io.Reader) (T, error) {
 	}
 	return decoded.Root, nil
 }
+
+// xmlNode is an element of a decoded xml document, unlike decodeXML it keeps the order of elements
+type xmlNode struct {
+	Name     string
+	Text     string
+	Children []*xmlNode
+}
+
+func decodeXMLTree(r io.Reader) (*xmlNode, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "xml#Decoder.Token")
+		}
+
+		current := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{Name: t.Name.Local}
+			current.Children = append(current.Children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			current.Text += string(t)
+		}
+	}
+
+	if len(root.Children) == 0 {
+		return nil, errors.New("xml document has no root element")
+	}
+	return root.Children[0], nil
+}
+
+// Child returns the first child element found by a slash separated path
+func (n *xmlNode) Child(path string) *xmlNode {
+	node := n
+	for _, name := range strings.Split(path, "/") {
+		if node == nil {
+			return nil
+		}
+		var next *xmlNode
+		for _, child := range node.Children {
+			if child.Name == name {
+				next = child
+				break
+			}
+		}
+		node = next
+	}
+	return node
+}
+
+// Value returns trimmed text of a child element, or an empty string when the element is missing
+func (n *xmlNode) Value(path string) string {
+	child := n.Child(path)
+	if child == nil {
+		return ""
+	}
+	return strings.TrimSpace(child.Text)
+}
+
+// Float returns the first number in text of a child element, values can be followed by other values or elements
+func (n *xmlNode) Float(path string) float64 {
+	fields := strings.Fields(n.Value(path))
+	if len(fields) == 0 {
+		return 0
+	}
+	value, _ := strconv.ParseFloat(fields[0], 64)
+	return value
+}
+
+func (n *xmlNode) Int(path string) int {
+	return int(n.Float(path))
+}
@@ -1,117 +1,220 @@
 package main
 
 import (
-	"encoding/binary"
-	"errors"
-	"hash/crc32"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
 	"io/fs"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
-	"slices"
 	"strings"
-	"sync"
+	"sync/atomic"
 
-	"github.com/pierrec/lz4/v4"
+	"github.com/cufee/aftermath-assets/dvpl"
+	"github.com/pkg/errors"
 )
 
-var decryptExtensions = []string{".yaml", ".xml", ".txt"}
+type decryptStats struct {
+	Decrypted atomic.Int64
+	Copied    atomic.Int64
+	Unchanged atomic.Int64
+	Skipped   atomic.Int64
+	Failed    atomic.Int64
+}
 
-func decryptDVPL(inputBuf []byte) ([]byte, error) {
-	dataBuf := inputBuf[:len(inputBuf)-20]
-	footerBuf := inputBuf[len(inputBuf)-20:]
+func (s *decryptStats) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int64("decrypted", s.Decrypted.Load()),
+		slog.Int64("copied", s.Copied.Load()),
+		slog.Int64("unchanged", s.Unchanged.Load()),
+		slog.Int64("skipped", s.Skipped.Load()),
+		slog.Int64("failed", s.Failed.Load()),
+	)
+}
 
-	originalSize := binary.LittleEndian.Uint32(footerBuf[:4])
-	compressedSize := binary.LittleEndian.Uint32(footerBuf[4:8])
-	if int(compressedSize) != len(dataBuf) {
-		return nil, errors.New("invalid compressed data length")
-	}
+type decrypter struct {
+	pool  *workerPool
+	cache *cacheManifest
 
-	crc32DataSum := binary.LittleEndian.Uint32(footerBuf[8:12])
-	if crc32DataSum != crc32.ChecksumIEEE(dataBuf) {
-		return nil, errors.New("invalid crc32 sum")
-	}
+	rules fileRules
+	// rulesRoot is the directory rules are matched relative to, the same way as paths in filelist.txt
+	rulesRoot string
+	// passthrough files are not dvpl encoded and are copied as is when selected by rules
+	passthrough bool
 
-	compressType := binary.LittleEndian.Uint32(footerBuf[12:16])
-	outputBuf := make([]byte, originalSize)
-	if compressType == 0 {
-		outputBuf = dataBuf
-	} else {
-		actualOutputSize, err := lz4.UncompressBlock(dataBuf, outputBuf)
+	stats decryptStats
+}
+
+// decryptDir decrypts all files in a directory tree into outDir, errors are collected for every file that failed.
+// Files recorded in the cache manifest are skipped if they did not change.
+func (d *decrypter) decryptDir(ctx context.Context, path string, outDir string) error {
+	group := d.pool.Group(ctx)
+	err := filepath.WalkDir(path, func(entryPath string, entry fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if err != nil {
-			return nil, errors.New("failed to uncompressed lz4")
+			d.stats.Failed.Add(1)
+			group.Fail(entryPath, errors.Wrap(err, "failed to read a directory"))
+			return nil
 		}
-		outputBuf = outputBuf[:actualOutputSize]
+		if entry.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(path, entryPath)
+		if err != nil {
+			return err
+		}
+		group.Go(entryPath, func() error {
+			err := d.decryptFile(ctx, entryPath, filepath.Join(outDir, filepath.Dir(rel)))
+			if err != nil {
+				d.stats.Failed.Add(1)
+			}
+			return err
+		})
+		return nil
+	})
+	if err != nil && ctx.Err() == nil {
+		group.Fail(path, err)
 	}
-	return outputBuf, nil
+
+	return group.Wait()
 }
 
-func decryptDir(path string, dir []fs.DirEntry, outDir string) error {
-	var wg sync.WaitGroup
-	for _, entry := range dir {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-
-			entryPath := filepath.Join(path, entry.Name())
-
-			if !entry.IsDir() {
-				err := decryptFile(entryPath, outDir)
-				if err != nil {
-					log.Println("failed to decrypt a file", entryPath, err)
-				}
-				return
-			}
+func (d *decrypter) decryptFile(ctx context.Context, path string, outDir string) error {
+	rulePath := path
+	if rel, err := filepath.Rel(d.rulesRoot, path); err == nil {
+		rulePath = filepath.ToSlash(rel)
+	}
 
-			dir, err := os.ReadDir(entryPath)
-			if err != nil {
-				log.Println("failed to read a directory", entryPath, err)
-				return
-			}
+	if !d.rules.Selected(rulePath, d.passthrough) {
+		d.stats.Skipped.Add(1)
+		report.Skip(path)
+		return nil
+	}
+	cleanPath, encrypted := strings.CutSuffix(path, ".dvpl")
+	if !encrypted {
+		return d.copyFile(ctx, path, filepath.Join(outDir, filepath.Base(path)))
+	}
 
-			err = decryptDir(entryPath, dir, filepath.Join(outDir, entry.Name()))
-			if err != nil {
-				log.Println("failed to decrypt a directory", entryPath, err)
-			}
-		}()
+	outPath := filepath.Join(outDir, filepath.Base(cleanPath))
+	if d.unchanged(path, outPath) {
+		return nil
+	}
+	loggerFrom(ctx).Debug("decrypting", "path", path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
 	}
 
-	wg.Wait()
+	reader, err := dvpl.NewReader(f, info.Size())
+	if err != nil {
+		return err
+	}
+
+	err = d.write(ctx, path, outPath, reader)
+	if err != nil {
+		return err
+	}
+	d.stats.Decrypted.Add(1)
+	report.File("decrypted", path)
 	return nil
 }
 
-func decryptFile(path string, outDir string) error {
-	cleanPath, ok := strings.CutSuffix(path, ".dvpl")
-	if !ok {
-		// log.Println("skipping - not encrypted:", path)
+func (d *decrypter) copyFile(ctx context.Context, path string, outPath string) error {
+	if d.unchanged(path, outPath) {
 		return nil
 	}
+	loggerFrom(ctx).Debug("copying", "path", path)
 
-	ext := filepath.Ext(cleanPath)
-	if !slices.Contains(decryptExtensions, ext) {
-		// log.Println("skipping - extension not registered:", path)
-		return nil
+	f, err := os.Open(path)
+	if err != nil {
+		return err
 	}
-	log.Println("decrypting", path)
+	defer f.Close()
 
-	raw, err := os.ReadFile(path)
+	err = d.write(ctx, path, outPath, f)
 	if err != nil {
 		return err
 	}
+	d.stats.Copied.Add(1)
+	report.File("copied", path)
+	return nil
+}
+
+func (d *decrypter) unchanged(path, outPath string) bool {
+	if d.cache == nil {
+		return false
+	}
+	unchanged, err := d.cache.Unchanged(path, path, outPath)
+	if err != nil || !unchanged {
+		return false
+	}
+	d.stats.Unchanged.Add(1)
+	report.File("unchanged", path)
+	return true
+}
 
-	err = os.MkdirAll(outDir, os.ModePerm)
+// write writes data from r to outPath and records the file in the cache manifest.
+// Data is written to a temporary file first, so outPath is only replaced once r was read and verified in full.
+func (d *decrypter) write(ctx context.Context, path, outPath string, r io.Reader) error {
+	err := os.MkdirAll(filepath.Dir(outPath), os.ModePerm)
 	if err != nil {
 		return err
 	}
 
-	decrypted, err := decryptDVPL(raw)
+	out, err := os.CreateTemp(filepath.Dir(outPath), "."+filepath.Base(outPath)+".*.tmp")
 	if err != nil {
 		return err
 	}
+	defer os.Remove(out.Name())
+	defer out.Close()
 
-	err = os.WriteFile(filepath.Join(outDir, filepath.Base(cleanPath)), decrypted, os.ModePerm)
+	// CreateTemp only grants access to the owner, outputs keep the permissions os.Create would give them
+	err = out.Chmod(0644)
 	if err != nil {
 		return err
 	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(out, hash), &contextReader{ctx: ctx, r: r})
+	if err != nil {
+		return err
+	}
+	err = out.Close()
+	if err != nil {
+		return err
+	}
+	err = os.Rename(out.Name(), outPath)
+	if err != nil {
+		return err
+	}
+
+	if d.cache != nil {
+		return d.cache.Record(path, path, outPath, size, hex.EncodeToString(hash.Sum(nil)))
+	}
 	return nil
 }
+
+// contextReader stops reading once ctx is done, so large files do not block cancellation
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cufee/aftermath-assets/dvpl"
+	"github.com/matryer/is"
+)
+
+func TestDecryptFileInvalid(t *testing.T) {
+	is := is.New(t)
+
+	dump := t.TempDir()
+	out := t.TempDir()
+
+	encoded, err := dvpl.Encode([]byte("<root><name>value</name></root>"), dvpl.CompressionNone)
+	is.NoErr(err)
+	// the footer is intact, so the crc32 sum only fails once all data was read
+	encoded[0] ^= 0xff
+	is.NoErr(os.WriteFile(filepath.Join(dump, "list.xml.dvpl"), encoded, os.ModePerm))
+
+	d := &decrypter{pool: newWorkerPool(1), rulesRoot: dump}
+	err = d.decryptFile(context.Background(), filepath.Join(dump, "list.xml.dvpl"), out)
+	is.True(err != nil)
+
+	entries, err := os.ReadDir(out)
+	is.NoErr(err)
+	is.Equal(len(entries), 0) // no partial or temporary output is left
+}
@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
-	"log"
+	"io/fs"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -17,7 +19,7 @@ import (
 	"golang.org/x/text/language"
 )
 
-func downloadAssetsFromSteam(email *emailClient) error {
+func downloadAssetsFromSteam(ctx context.Context, email *emailClient) error {
 	var dargs []string
 	dargs = append(dargs, "-app")
 	dargs = append(dargs, args.AppID)
@@ -35,9 +37,11 @@ func downloadAssetsFromSteam(email *emailClient) error {
 	dargs = append(dargs, "-dir")
 	dargs = append(dargs, args.DumpPath)
 
-	cmd := exec.Command(args.DownloaderPath, dargs...)
+	cmd := exec.CommandContext(ctx, args.DownloaderPath, dargs...)
 	cmd.WaitDelay = time.Minute * 15
 
+	logger := loggerFrom(ctx)
+
 	startedAt := time.Now().Add(time.Second * -1)
 
 	ptmx, err := pty.Start(cmd)
@@ -49,7 +53,7 @@ func downloadAssetsFromSteam(email *emailClient) error {
 	input := func(text string) {
 		_, err := ptmx.Write([]byte(text + "\n"))
 		if err != nil {
-			log.Print("error writing to pty", "error", err)
+			logger.Error("failed to write to pty", "error", err)
 		}
 	}
 
@@ -65,7 +69,7 @@ func downloadAssetsFromSteam(email *emailClient) error {
 	go func() {
 		_, err := io.Copy(combinedWriter, ptmx)
 		if err != nil {
-			log.Print("io.Copy from ptmx finished", err)
+			logger.Debug("stopped reading downloader output", "error", err)
 		}
 	}()
 
@@ -78,7 +82,7 @@ func downloadAssetsFromSteam(email *emailClient) error {
 			if strings.Contains(fullOut, "STEAM GUARD! Please enter the auth code sent to the email") {
 				select {
 				case steamGuardRequired <- struct{}{}:
-					println() // insert a newline
+					fmt.Fprintln(os.Stderr) // the prompt does not end with a newline
 				default:
 				}
 			}
@@ -91,7 +95,7 @@ func downloadAssetsFromSteam(email *emailClient) error {
 		}
 	}()
 
-	startCtx, cancelStart := context.WithTimeout(context.Background(), time.Minute)
+	startCtx, cancelStart := context.WithTimeout(ctx, time.Minute)
 	defer cancelStart()
 
 	select {
@@ -101,12 +105,16 @@ func downloadAssetsFromSteam(email *emailClient) error {
 
 		var counter int
 		for range ticker.C {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
 			counter++
 			if counter > 3 {
 				return errors.New("failed to find a steam guard code")
 			}
 
-			log.Print("Checking email for Steam Guard code", counter)
+			logger.Info("checking email for a steam guard code", "attempt", counter)
 			code, err := email.GetSteamCode(startedAt)
 			if errors.Is(err, ErrCodeNotFound) {
 				continue
@@ -115,16 +123,16 @@ func downloadAssetsFromSteam(email *emailClient) error {
 				return errors.Wrap(err, "failed to get steam auth code from email")
 			}
 
-			log.Print("Entering Steam Guard code from email")
+			logger.Info("entering a steam guard code from email")
 			input(code)
 			break
 		}
 
 	case <-downloadStarted:
-		log.Print("Download started")
+		logger.Info("download started")
 
 	case <-startCtx.Done():
-		log.Print("Failed to start a download, timeout")
+		logger.Warn("download did not start in time")
 	}
 
 	err = cmd.Wait()
@@ -134,13 +142,19 @@ func downloadAssetsFromSteam(email *emailClient) error {
 	return nil
 }
 
-func mergeMissingStrings(client *wargamingCDNClient, dir string) error {
-	stringFiles, err := os.ReadDir(filepath.Join(dir, "Strings"))
+// mergeMissingStrings reads localized strings from fsys, adds strings missing from the game files and writes them to outDir as json
+func mergeMissingStrings(ctx context.Context, client *wargamingCDNClient, fsys fs.FS, outDir string) error {
+	stringFiles, err := fs.ReadDir(fsys, "Strings")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Join(outDir, "Strings"), os.ModePerm)
 	if err != nil {
 		return err
 	}
 
-	missingStrings, err := client.MissingStrings("en", "ru", "pl", "de", "fr", "es", "tr", "cs", "th", "vi", "ko")
+	missingStrings, err := client.MissingStrings(ctx, "en", "ru", "pl", "de", "fr", "es", "tr", "cs", "th", "vi", "ko")
 	if err != nil {
 		return err
 	}
@@ -156,7 +170,7 @@ func mergeMissingStrings(client *wargamingCDNClient, dir string) error {
 			return err
 		}
 
-		stringsFile, err := os.ReadFile(filepath.Join(dir, "Strings", file.Name()))
+		stringsFile, err := fs.ReadFile(fsys, path.Join("Strings", file.Name()))
 		if err != nil {
 			return err
 		}
@@ -178,7 +192,8 @@ func mergeMissingStrings(client *wargamingCDNClient, dir string) error {
 			return err
 		}
 
-		err = os.WriteFile(filepath.Join(dir, "Strings", fileName+".json"), buf, os.ModePerm)
+		loggerFrom(ctx).Debug("merged missing strings", "locale", tag.String(), "path", path.Join("Strings", file.Name()))
+		err = os.WriteFile(filepath.Join(outDir, "Strings", fileName+".json"), buf, os.ModePerm)
 		if err != nil {
 			return err
 		}
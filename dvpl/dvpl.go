@@ -0,0 +1,83 @@
+// Package dvpl implements encoding and decoding of the dvpl file format used by World of Tanks Blitz.
+// Every dvpl file is a (usually lz4 compressed) block of data followed by a 20 byte footer.
+package dvpl
+
+import (
+	"hash/crc32"
+
+	"github.com/pierrec/lz4/v4"
+	"github.com/pkg/errors"
+)
+
+// Decode decodes a complete dvpl file held in memory
+func Decode(inputBuf []byte) ([]byte, error) {
+	footer, err := ParseFooter(inputBuf)
+	if err != nil {
+		return nil, err
+	}
+
+	dataBuf := inputBuf[:len(inputBuf)-FooterSize]
+	err = footer.validate(int64(len(dataBuf)))
+	if err != nil {
+		return nil, err
+	}
+	if footer.CRC32 != crc32.ChecksumIEEE(dataBuf) {
+		return nil, ErrCRCMismatch
+	}
+
+	if footer.Compression == CompressionNone {
+		return dataBuf, nil
+	}
+
+	outputBuf := make([]byte, footer.OriginalSize)
+	actualOutputSize, err := lz4.UncompressBlock(dataBuf, outputBuf)
+	if err != nil {
+		return nil, errors.Wrap(ErrCorrupted, err.Error())
+	}
+	if actualOutputSize != len(outputBuf) {
+		return nil, errors.Wrap(ErrSizeMismatch, "decompressed data is shorter than the original size")
+	}
+	return outputBuf, nil
+}
+
+// Encode compresses the data with the requested compression type and appends a footer.
+// Incompressible data is stored as is, the same way the game client does it.
+func Encode(inputBuf []byte, compression uint32) ([]byte, error) {
+	dataBuf := inputBuf
+	if compression != CompressionNone {
+		compressed := make([]byte, lz4.CompressBlockBound(len(inputBuf)))
+
+		var size int
+		var err error
+		switch compression {
+		case CompressionLZ4:
+			var c lz4.Compressor
+			size, err = c.CompressBlock(inputBuf, compressed)
+		case CompressionLZ4HC:
+			var c lz4.CompressorHC
+			size, err = c.CompressBlock(inputBuf, compressed)
+		default:
+			return nil, errors.Wrapf(ErrUnknownCompression, "compression type %d", compression)
+		}
+		if err != nil {
+			return nil, errors.New("failed to compress lz4")
+		}
+
+		if size > 0 && size < len(inputBuf) {
+			dataBuf = compressed[:size]
+		} else {
+			compression = CompressionNone
+		}
+	}
+
+	footer := Footer{
+		OriginalSize:   uint32(len(inputBuf)),
+		CompressedSize: uint32(len(dataBuf)),
+		CRC32:          crc32.ChecksumIEEE(dataBuf),
+		Compression:    compression,
+	}
+
+	outputBuf := make([]byte, 0, len(dataBuf)+FooterSize)
+	outputBuf = append(outputBuf, dataBuf...)
+	return footer.AppendFooter(outputBuf), nil
+}
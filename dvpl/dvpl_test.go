@@ -0,0 +1,95 @@
+package dvpl
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+var compressionTypes = []uint32{CompressionNone, CompressionLZ4, CompressionLZ4HC}
+
+func testInputs() [][]byte {
+	random := make([]byte, 256<<10)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	return [][]byte{
+		{},
+		[]byte("a"),
+		[]byte("#maps:17_karelia_ka:17_karelia_ka.sc2: Rockfield\n"),
+		bytes.Repeat([]byte("battleType/regular: Regular Battle\n"), 512),
+		// matches further back than the window size
+		append(append(bytes.Repeat([]byte("tank"), 1<<15), random[:1<<17]...), bytes.Repeat([]byte("tank"), 1<<15)...),
+		random,
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	is := is.New(t)
+
+	for _, compression := range compressionTypes {
+		for _, input := range testInputs() {
+			encoded, err := Encode(input, compression)
+			is.NoErr(err)
+			is.Equal(encoded[len(encoded)-4:], footerMagic)
+
+			decoded, err := Decode(encoded)
+			is.NoErr(err)
+			is.Equal(decoded, input)
+		}
+	}
+
+	_, err := Encode([]byte("data"), 3)
+	is.True(err != nil)
+}
+
+func TestReader(t *testing.T) {
+	is := is.New(t)
+
+	for _, compression := range compressionTypes {
+		for _, input := range testInputs() {
+			encoded, err := Encode(input, compression)
+			is.NoErr(err)
+
+			r, err := NewReader(bytes.NewReader(encoded), int64(len(encoded)))
+			is.NoErr(err)
+			is.Equal(r.Size(), int64(len(input)))
+
+			decoded, err := io.ReadAll(r)
+			is.NoErr(err)
+			is.Equal(decoded, input)
+
+			if len(input) < 16 {
+				continue
+			}
+
+			off := int64(len(input) / 3)
+			buf := make([]byte, 8)
+			n, err := r.ReadAt(buf, off)
+			is.NoErr(err)
+			is.Equal(buf[:n], input[off:off+8])
+
+			_, err = r.Seek(-8, io.SeekEnd)
+			is.NoErr(err)
+			tail, err := io.ReadAll(r)
+			is.NoErr(err)
+			is.Equal(tail, input[len(input)-8:])
+		}
+	}
+}
+
+func TestReaderCorrupted(t *testing.T) {
+	is := is.New(t)
+
+	encoded, err := Encode(bytes.Repeat([]byte("battleType/regular: Regular Battle\n"), 64), CompressionLZ4)
+	is.NoErr(err)
+	encoded[0] ^= 0xFF
+
+	r, err := NewReader(bytes.NewReader(encoded), int64(len(encoded)))
+	is.NoErr(err)
+
+	_, err = io.ReadAll(r)
+	is.True(err != nil)
+}
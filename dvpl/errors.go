@@ -0,0 +1,43 @@
+package dvpl
+
+import "github.com/pkg/errors"
+
+var (
+	// ErrTruncated is returned when the file is shorter than its footer says
+	ErrTruncated = errors.New("dvpl: truncated data")
+	// ErrCRCMismatch is returned when the crc32 sum of the data does not match the footer
+	ErrCRCMismatch = errors.New("dvpl: crc32 sum mismatch")
+	// ErrSizeMismatch is returned when the decoded data size does not match the footer
+	ErrSizeMismatch = errors.New("dvpl: size mismatch")
+	// ErrUnknownCompression is returned for compression types other than none, lz4 and lz4hc
+	ErrUnknownCompression = errors.New("dvpl: unknown compression type")
+	// ErrCorrupted is returned when the compressed data cannot be decompressed
+	ErrCorrupted = errors.New("dvpl: corrupted lz4 data")
+)
+
+// lz4 cannot expand a single byte into more than 255 bytes, anything above that is a broken footer
+const maxCompressionRatio = 255
+
+// validate checks that the footer describes a file with a given data size
+func (f Footer) validate(dataSize int64) error {
+	switch f.Compression {
+	case CompressionNone:
+		if f.OriginalSize != f.CompressedSize {
+			return errors.Wrap(ErrSizeMismatch, "uncompressed data size does not match the original size")
+		}
+	case CompressionLZ4, CompressionLZ4HC:
+		if int64(f.OriginalSize) > int64(f.CompressedSize)*maxCompressionRatio+16 {
+			return errors.Wrap(ErrSizeMismatch, "original size is too large for the compressed data")
+		}
+	default:
+		return errors.Wrapf(ErrUnknownCompression, "compression type %d", f.Compression)
+	}
+
+	if dataSize < int64(f.CompressedSize) {
+		return errors.Wrap(ErrTruncated, "data is shorter than the compressed size")
+	}
+	if dataSize > int64(f.CompressedSize) {
+		return errors.Wrap(ErrSizeMismatch, "data is longer than the compressed size")
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package dvpl
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// FooterSize is the size of the footer appended to every dvpl file
+const FooterSize = 20
+
+var footerMagic = []byte("DVPL")
+
+const (
+	CompressionNone  uint32 = 0
+	CompressionLZ4   uint32 = 1
+	CompressionLZ4HC uint32 = 2
+)
+
+// Footer describes the data stored in a dvpl file
+type Footer struct {
+	OriginalSize   uint32
+	CompressedSize uint32
+	CRC32          uint32
+	Compression    uint32
+}
+
+// ParseFooter decodes a footer from the last FooterSize bytes of buf
+func ParseFooter(buf []byte) (Footer, error) {
+	if len(buf) < FooterSize {
+		return Footer{}, errors.Wrap(ErrTruncated, "missing footer")
+	}
+	footerBuf := buf[len(buf)-FooterSize:]
+
+	return Footer{
+		OriginalSize:   binary.LittleEndian.Uint32(footerBuf[:4]),
+		CompressedSize: binary.LittleEndian.Uint32(footerBuf[4:8]),
+		CRC32:          binary.LittleEndian.Uint32(footerBuf[8:12]),
+		Compression:    binary.LittleEndian.Uint32(footerBuf[12:16]),
+	}, nil
+}
+
+// ReadFooter reads the footer of a dvpl file of a given size without reading the data
+func ReadFooter(r io.ReaderAt, size int64) (Footer, error) {
+	if size < FooterSize {
+		return Footer{}, errors.Wrap(ErrTruncated, "missing footer")
+	}
+
+	footerBuf := make([]byte, FooterSize)
+	_, err := r.ReadAt(footerBuf, size-FooterSize)
+	if err != nil {
+		return Footer{}, errors.Wrap(err, "failed to read footer")
+	}
+	return ParseFooter(footerBuf)
+}
+
+// AppendFooter appends an encoded footer to buf
+func (f Footer) AppendFooter(buf []byte) []byte {
+	buf = binary.LittleEndian.AppendUint32(buf, f.OriginalSize)
+	buf = binary.LittleEndian.AppendUint32(buf, f.CompressedSize)
+	buf = binary.LittleEndian.AppendUint32(buf, f.CRC32)
+	buf = binary.LittleEndian.AppendUint32(buf, f.Compression)
+	return append(buf, footerMagic...)
+}
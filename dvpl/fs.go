@@ -0,0 +1,206 @@
+package dvpl
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+	"time"
+)
+
+const fileSuffix = ".dvpl"
+
+// FS exposes a directory tree of dvpl files as if it was already decoded.
+// The .dvpl suffix is removed from names and files are decoded on Open, other files are served as is.
+type FS struct {
+	fsys fs.FS
+}
+
+var _ fs.ReadDirFS = &FS{}
+var _ fs.StatFS = &FS{}
+
+// NewFS returns a decoding view of fsys
+func NewFS(fsys fs.FS) *FS {
+	return &FS{fsys: fsys}
+}
+
+func (f *FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	encoded, err := f.fsys.Open(name + fileSuffix)
+	if err == nil {
+		file, err := newFile(encoded)
+		if err != nil {
+			encoded.Close()
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return file, nil
+	}
+
+	file, err := f.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := file.Stat(); err == nil && info.IsDir() {
+		return &dir{File: file, fsys: f, name: name}, nil
+	}
+	return file, nil
+}
+
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	file, err := f.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return file.Stat()
+}
+
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(f.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return f.decodeEntries(name, entries), nil
+}
+
+// decodeEntries renames dvpl entries, a dvpl file shadows a plain file with the same name
+func (f *FS) decodeEntries(dirName string, entries []fs.DirEntry) []fs.DirEntry {
+	decoded := make([]fs.DirEntry, 0, len(entries))
+	seen := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), fileSuffix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		seen[name] = struct{}{}
+		decoded = append(decoded, &dirEntry{DirEntry: entry, fsys: f, name: name, path: joinPath(dirName, name)})
+	}
+	for _, entry := range entries {
+		if _, ok := seen[entry.Name()]; ok {
+			continue
+		}
+		if strings.HasSuffix(entry.Name(), fileSuffix) && !entry.IsDir() {
+			continue
+		}
+		decoded = append(decoded, entry)
+	}
+
+	slices.SortFunc(decoded, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return decoded
+}
+
+func joinPath(dir, name string) string {
+	if dir == "." {
+		return name
+	}
+	return dir + "/" + name
+}
+
+type file struct {
+	*Reader
+	encoded fs.File
+	info    fileInfo
+}
+
+func newFile(encoded fs.File) (*file, error) {
+	info, err := encoded.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	src, ok := encoded.(io.ReaderAt)
+	if !ok {
+		data, err := io.ReadAll(encoded)
+		if err != nil {
+			return nil, err
+		}
+		src = bytes.NewReader(data)
+	}
+
+	reader, err := NewReader(src, info.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	name, _ := strings.CutSuffix(info.Name(), fileSuffix)
+	return &file{
+		Reader:  reader,
+		encoded: encoded,
+		info:    fileInfo{name: name, size: reader.Size(), mode: info.Mode(), modTime: info.ModTime()},
+	}, nil
+}
+
+func (f *file) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *file) Close() error {
+	return f.encoded.Close()
+}
+
+type fileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i fileInfo) Name() string       { return i.name }
+func (i fileInfo) Size() int64        { return i.size }
+func (i fileInfo) Mode() fs.FileMode  { return i.mode }
+func (i fileInfo) ModTime() time.Time { return i.modTime }
+func (i fileInfo) IsDir() bool        { return false }
+func (i fileInfo) Sys() any           { return nil }
+
+type dirEntry struct {
+	fs.DirEntry
+	fsys *FS
+	name string
+	path string
+}
+
+func (e *dirEntry) Name() string {
+	return e.name
+}
+
+func (e *dirEntry) Info() (fs.FileInfo, error) {
+	return e.fsys.Stat(e.path)
+}
+
+type dir struct {
+	fs.File
+	fsys    *FS
+	name    string
+	entries []fs.DirEntry
+	read    bool
+}
+
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		entries, err := d.fsys.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries = entries
+		d.read = true
+	}
+
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
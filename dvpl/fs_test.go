@@ -0,0 +1,37 @@
+package dvpl
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matryer/is"
+)
+
+func TestFS(t *testing.T) {
+	is := is.New(t)
+
+	files := map[string]string{
+		"version.txt":         "11.1.0.743_4002766 release/11.1.0 WOTB_Win7",
+		"Strings/en.yaml":     "battleType/regular: Regular Battle\n",
+		"XML/item_defs/a.xml": "<root></root>",
+	}
+
+	raw := fstest.MapFS{
+		"plain.txt": &fstest.MapFile{Data: []byte("plain")},
+	}
+	for name, content := range files {
+		encoded, err := Encode([]byte(content), CompressionLZ4HC)
+		is.NoErr(err)
+		raw[name+fileSuffix] = &fstest.MapFile{Data: encoded}
+	}
+
+	fsys := NewFS(raw)
+	is.NoErr(fstest.TestFS(fsys, "version.txt", "Strings/en.yaml", "XML/item_defs/a.xml", "plain.txt"))
+
+	for name, content := range files {
+		data, err := fs.ReadFile(fsys, name)
+		is.NoErr(err)
+		is.Equal(string(data), content)
+	}
+}
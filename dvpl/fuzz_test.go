@@ -0,0 +1,133 @@
+package dvpl
+
+import (
+	"bytes"
+	"hash/crc32"
+	"io"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+)
+
+func TestDecodeErrors(t *testing.T) {
+	is := is.New(t)
+
+	valid, err := Encode(bytes.Repeat([]byte("battleType/regular: Regular Battle\n"), 64), CompressionLZ4)
+	is.NoErr(err)
+
+	footer, err := ParseFooter(valid)
+	is.NoErr(err)
+	data := valid[:len(valid)-FooterSize]
+
+	withFooter := func(data []byte, f Footer) []byte {
+		return f.AppendFooter(append([]byte{}, data...))
+	}
+
+	badCRC := footer
+	badCRC.CRC32++
+	badCompression := footer
+	badCompression.Compression = 7
+	badSize := footer
+	badSize.OriginalSize++
+	badCompressedSize := footer
+	badCompressedSize.CompressedSize++
+
+	cases := []struct {
+		input []byte
+		err   error
+	}{
+		{nil, ErrTruncated},
+		{[]byte("DVPL"), ErrTruncated},
+		{withFooter(data, badCompressedSize), ErrTruncated},
+		{withFooter(data, badCRC), ErrCRCMismatch},
+		{withFooter(data, badCompression), ErrUnknownCompression},
+		{withFooter(data, badSize), ErrSizeMismatch},
+		{withFooter(append(data, 0), footer), ErrSizeMismatch},
+	}
+
+	for _, c := range cases {
+		_, err := Decode(c.input)
+		is.True(errors.Is(err, c.err))
+
+		r, err := NewReader(bytes.NewReader(c.input), int64(len(c.input)))
+		if err == nil {
+			_, err = io.ReadAll(r)
+		}
+		is.True(errors.Is(err, c.err))
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	for _, compression := range compressionTypes {
+		for _, input := range [][]byte{nil, []byte("a"), bytes.Repeat([]byte("tank"), 64)} {
+			encoded, err := Encode(input, compression)
+			if err != nil {
+				f.Fatal(err)
+			}
+			f.Add(encoded)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, input []byte) {
+		decoded, decodeErr := Decode(input)
+		if decodeErr == nil {
+			footer, _ := ParseFooter(input)
+			if len(decoded) != int(footer.OriginalSize) {
+				t.Fatalf("decoded %d bytes, footer has %d", len(decoded), footer.OriginalSize)
+			}
+		}
+
+		r, err := NewReader(bytes.NewReader(input), int64(len(input)))
+		if err != nil {
+			return
+		}
+		streamed, streamErr := io.ReadAll(r)
+		if (decodeErr == nil) != (streamErr == nil) {
+			// the streaming decoder is allowed to be stricter about malformed lz4 blocks
+			if !errors.Is(streamErr, ErrCorrupted) && !errors.Is(streamErr, ErrSizeMismatch) {
+				t.Fatalf("decode error %v, reader error %v", decodeErr, streamErr)
+			}
+			return
+		}
+		if streamErr == nil && !bytes.Equal(decoded, streamed) {
+			t.Fatal("reader output does not match decode output")
+		}
+	})
+}
+
+// FuzzDecodeBlock fuzzes the decompression itself, every input gets a valid crc32 sum
+func FuzzDecodeBlock(f *testing.F) {
+	for _, compression := range compressionTypes {
+		encoded, err := Encode(bytes.Repeat([]byte("tank"), 64), compression)
+		if err != nil {
+			f.Fatal(err)
+		}
+		footer, _ := ParseFooter(encoded)
+		f.Add(encoded[:len(encoded)-FooterSize], footer.OriginalSize, compression)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte, originalSize uint32, compression uint32) {
+		footer := Footer{
+			OriginalSize:   originalSize,
+			CompressedSize: uint32(len(data)),
+			CRC32:          crc32.ChecksumIEEE(data),
+			Compression:    compression,
+		}
+		input := footer.AppendFooter(append([]byte{}, data...))
+
+		decoded, err := Decode(input)
+		if err == nil && len(decoded) != int(originalSize) {
+			t.Fatalf("decoded %d bytes, footer has %d", len(decoded), originalSize)
+		}
+
+		r, err := NewReader(bytes.NewReader(input), int64(len(input)))
+		if err != nil {
+			return
+		}
+		streamed, err := io.ReadAll(r)
+		if err == nil && len(streamed) != int(originalSize) {
+			t.Fatalf("streamed %d bytes, footer has %d", len(streamed), originalSize)
+		}
+	})
+}
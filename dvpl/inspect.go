@@ -0,0 +1,39 @@
+package dvpl
+
+import (
+	"hash/crc32"
+	"io"
+	"strconv"
+)
+
+// CompressionName returns a readable name of the footer compression type
+func (f Footer) CompressionName() string {
+	switch f.Compression {
+	case CompressionNone:
+		return "none"
+	case CompressionLZ4:
+		return "lz4"
+	case CompressionLZ4HC:
+		return "lz4hc"
+	default:
+		return "unknown(" + strconv.FormatUint(uint64(f.Compression), 10) + ")"
+	}
+}
+
+// Ratio returns the compression ratio of the data, original size divided by compressed size
+func (f Footer) Ratio() float64 {
+	if f.CompressedSize == 0 {
+		return 0
+	}
+	return float64(f.OriginalSize) / float64(f.CompressedSize)
+}
+
+// VerifyCRC checks the crc32 sum of the compressed data without decompressing it
+func VerifyCRC(r io.ReaderAt, footer Footer) (bool, error) {
+	crc := crc32.NewIEEE()
+	_, err := io.Copy(crc, io.NewSectionReader(r, 0, int64(footer.CompressedSize)))
+	if err != nil {
+		return false, err
+	}
+	return crc.Sum32() == footer.CRC32, nil
+}
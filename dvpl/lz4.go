@@ -0,0 +1,140 @@
+package dvpl
+
+import (
+	"bufio"
+	"io"
+)
+
+const (
+	lz4WindowSize = 1 << 16
+	lz4WindowMask = lz4WindowSize - 1
+	lz4MinMatch   = 4
+)
+
+// lz4BlockReader decompresses a single lz4 block as a stream.
+// Unlike lz4.UncompressBlock, only the last 64KB of output are kept in memory, which is the maximum distance of a match.
+type lz4BlockReader struct {
+	src    *bufio.Reader
+	window [lz4WindowSize]byte
+	// written is the total number of decompressed bytes
+	written int64
+
+	literals int
+	matchLen int
+	matchOff int
+	token    byte
+	done     bool
+}
+
+func newLZ4BlockReader(src io.Reader) *lz4BlockReader {
+	return &lz4BlockReader{src: bufio.NewReader(src)}
+}
+
+func (d *lz4BlockReader) Read(p []byte) (int, error) {
+	var n int
+	for n < len(p) {
+		switch {
+		case d.literals > 0:
+			chunk := p[n:min(len(p), n+d.literals)]
+			read, err := io.ReadFull(d.src, chunk)
+			d.push(chunk[:read])
+			n += read
+			d.literals -= read
+			if err != nil {
+				return n, ErrCorrupted
+			}
+			if d.literals == 0 {
+				if err := d.readMatch(); err != nil {
+					return n, err
+				}
+			}
+
+		case d.matchLen > 0:
+			for ; d.matchLen > 0 && n < len(p); d.matchLen-- {
+				b := d.window[(d.written-int64(d.matchOff))&lz4WindowMask]
+				d.window[d.written&lz4WindowMask] = b
+				d.written++
+				p[n] = b
+				n++
+			}
+
+		case d.done:
+			return n, io.EOF
+
+		default:
+			if err := d.readToken(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (d *lz4BlockReader) push(data []byte) {
+	for _, b := range data {
+		d.window[d.written&lz4WindowMask] = b
+		d.written++
+	}
+}
+
+func (d *lz4BlockReader) readToken() error {
+	token, err := d.src.ReadByte()
+	if err == io.EOF {
+		d.done = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	d.token = token
+
+	literals, err := d.readLength(int(token >> 4))
+	if err != nil {
+		return err
+	}
+	d.literals = literals
+	if literals == 0 {
+		return d.readMatch()
+	}
+	return nil
+}
+
+// readMatch reads the match part of a sequence, the last sequence of a block only has literals
+func (d *lz4BlockReader) readMatch() error {
+	if _, err := d.src.Peek(1); err == io.EOF {
+		d.done = true
+		return nil
+	}
+
+	var offset [2]byte
+	if _, err := io.ReadFull(d.src, offset[:]); err != nil {
+		return ErrCorrupted
+	}
+	d.matchOff = int(offset[0]) | int(offset[1])<<8
+	if d.matchOff == 0 || int64(d.matchOff) > d.written {
+		return ErrCorrupted
+	}
+
+	length, err := d.readLength(int(d.token & 0xF))
+	if err != nil {
+		return err
+	}
+	d.matchLen = length + lz4MinMatch
+	return nil
+}
+
+func (d *lz4BlockReader) readLength(length int) (int, error) {
+	if length != 0xF {
+		return length, nil
+	}
+	for {
+		b, err := d.src.ReadByte()
+		if err != nil {
+			return 0, ErrCorrupted
+		}
+		length += int(b)
+		if b != 0xFF {
+			return length, nil
+		}
+	}
+}
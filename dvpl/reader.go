@@ -0,0 +1,151 @@
+package dvpl
+
+import (
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Reader decodes a dvpl file as a stream, the footer is read once when the reader is created.
+//
+// Sequential reads verify the crc32 sum and the decompressed size once the end of the data is reached.
+// ReadAt on a compressed file has to decompress everything before the requested offset.
+type Reader struct {
+	src    io.ReaderAt
+	footer Footer
+
+	// state of a sequential read
+	stream io.Reader
+	crc    hash.Hash32
+	pos    int64
+	offset int64
+}
+
+var _ io.ReadSeeker = &Reader{}
+var _ io.ReaderAt = &Reader{}
+
+// NewReader returns a reader decoding a dvpl file of a given size
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	footer, err := ReadFooter(r, size)
+	if err != nil {
+		return nil, err
+	}
+	err = footer.validate(size - FooterSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{src: r, footer: footer}, nil
+}
+
+// Footer returns the footer of a dvpl file
+func (r *Reader) Footer() Footer {
+	return r.footer
+}
+
+// Size returns the size of the decoded data
+func (r *Reader) Size() int64 {
+	return int64(r.footer.OriginalSize)
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.stream == nil || r.offset < r.pos {
+		r.reset()
+	}
+	if r.offset > r.pos {
+		// skip forward to the requested offset
+		if _, err := io.CopyN(io.Discard, readerFunc(r.read), r.offset-r.pos); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.read(p)
+	r.offset = r.pos
+	return n, err
+}
+
+func (r *Reader) read(p []byte) (int, error) {
+	n, err := r.stream.Read(p)
+	r.pos += int64(n)
+	if r.pos > r.Size() {
+		n -= int(r.pos - r.Size())
+		r.pos = r.Size()
+		return n, errors.Wrap(ErrSizeMismatch, "decoded data is larger than the original size")
+	}
+	if err != io.EOF {
+		return n, err
+	}
+
+	if r.crc.Sum32() != r.footer.CRC32 {
+		return n, ErrCRCMismatch
+	}
+	if r.pos != r.Size() {
+		return n, errors.Wrap(ErrSizeMismatch, "decoded data is shorter than the original size")
+	}
+	return n, io.EOF
+}
+
+func (r *Reader) reset() {
+	r.crc = crc32.NewIEEE()
+	data := io.TeeReader(io.NewSectionReader(r.src, 0, int64(r.footer.CompressedSize)), r.crc)
+
+	r.pos = 0
+	if r.footer.Compression == CompressionNone {
+		r.stream = data
+	} else {
+		r.stream = newLZ4BlockReader(data)
+	}
+}
+
+// ReadAt reads the decoded data at a given offset, it is safe for concurrent use.
+// Uncompressed files are read directly and the crc32 sum is not verified.
+func (r *Reader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if off >= r.Size() {
+		return 0, io.EOF
+	}
+
+	if r.footer.Compression == CompressionNone {
+		if remaining := r.Size() - off; int64(len(p)) > remaining {
+			n, err := r.src.ReadAt(p[:remaining], off)
+			if err == nil {
+				err = io.EOF
+			}
+			return n, err
+		}
+		return r.src.ReadAt(p, off)
+	}
+
+	reader := &Reader{src: r.src, footer: r.footer, offset: off}
+	n, err := io.ReadFull(reader, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.Size()
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative offset")
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) {
+	return f(p)
+}
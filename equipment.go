@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cufee/aftermath-assets/globalid"
+	"github.com/cufee/aftermath-assets/types"
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+)
+
+// equipmentLists are folders in XML/item_defs/vehicles with a list.xml of equipment instead of vehicles, with the id kind of their items
+var equipmentLists = map[string]globalid.Kind{"consumables": globalid.KindConsumable, "provisions": globalid.KindProvision}
+
+func init() {
+	for name, kind := range equipmentLists {
+		registerAsset(name, func() asset {
+			equipment := newEquipmentParser(name, kind)
+			return asset{File: name + ".json", Parsers: map[string]parseFunc{name: equipment.Items(), name + ".strings": equipment.Strings()}, Exporter: equipment}
+		})
+	}
+}
+
+// equipmentParser parses XML/item_defs/vehicles/<name>/list.xml, consumables and provisions are defined the same way
+type equipmentParser struct {
+	name string
+	kind globalid.Kind
+
+	names        map[string]map[language.Tag]string
+	descriptions map[string]map[language.Tag]string
+	items        map[string]types.Equipment
+	// descriptionKeys are keyed by item id
+	descriptionKeys map[string]string
+	lock            *sync.Mutex
+}
+
+func newEquipmentParser(name string, kind globalid.Kind) *equipmentParser {
+	return &equipmentParser{
+		name:            name,
+		kind:            kind,
+		lock:            &sync.Mutex{},
+		items:           make(map[string]types.Equipment),
+		names:           make(map[string]map[language.Tag]string),
+		descriptions:    make(map[string]map[language.Tag]string),
+		descriptionKeys: make(map[string]string),
+	}
+}
+
+func (p *equipmentParser) Items() *equipmentItemsParser {
+	return &equipmentItemsParser{p}
+}
+func (p *equipmentParser) Strings() *equipmentStringsParser {
+	return &equipmentStringsParser{p}
+}
+func (p *equipmentParser) Export(filePath string) (int, error) {
+	err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create path")
+	}
+
+	items := make(map[string]types.Equipment)
+	for id, item := range p.items {
+		item.LocalizedNames = reduceLocalizedNames(localizedOrEmpty(p.names[id]))
+		item.LocalizedDescriptions = reduceLocalizedNames(localizedOrEmpty(p.descriptions[id]))
+		items[id] = item
+	}
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create file")
+	}
+	defer f.Close()
+
+	e := json.NewEncoder(f)
+	e.SetIndent("", "  ")
+	return len(items), e.Encode(items)
+}
+
+type equipmentItemsParser struct {
+	*equipmentParser
+}
+
+func (p *equipmentItemsParser) Exclusive() bool {
+	return true
+}
+func (p *equipmentItemsParser) Match(path string) bool {
+	return vehicleItemsRegex.MatchString(path) && strings.HasSuffix(path, p.name+"/list.xml")
+}
+func (p *equipmentItemsParser) Parse(path string, r io.Reader) error {
+	root, err := decodeXMLTree(r)
+	if err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, node := range root.Children {
+		localID, err := strconv.Atoi(node.Value("id"))
+		if err != nil {
+			return errors.New("missing id for " + node.Name)
+		}
+		// equipment is shared by all nations and is encoded without one
+		globalID, err := globalid.EncodeItem(p.kind, "", localID)
+		if err != nil {
+			return errors.Wrap(err, "invalid id for "+node.Name)
+		}
+		id := fmt.Sprint(globalID)
+
+		item := types.Equipment{
+			ID:   id,
+			Key:  node.Value("userString"),
+			Name: node.Name,
+			Tags: strings.Fields(node.Value("tags")),
+
+			Cooldown: node.Float("script/cooldown"),
+			Duration: node.Float("script/duration"),
+
+			VehicleTags:         strings.Fields(node.Value("vehicleFilter/include/vehicle/tags")),
+			ExcludedVehicleTags: strings.Fields(node.Value("vehicleFilter/exclude/vehicle/tags")),
+		}
+		if item.Tags == nil {
+			item.Tags = []string{}
+		}
+		if script := node.Child("script"); script != nil {
+			for _, param := range script.Children {
+				if param.Name == "cooldown" || param.Name == "duration" || len(param.Children) > 0 {
+					continue
+				}
+				value, err := strconv.ParseFloat(strings.TrimSpace(param.Text), 64)
+				if err != nil {
+					continue
+				}
+				if item.Effects == nil {
+					item.Effects = make(map[string]float64)
+				}
+				item.Effects[param.Name] = value
+			}
+		}
+
+		p.items[id] = item
+		p.descriptionKeys[id] = node.Value("description")
+	}
+
+	return nil
+}
+
+// equipmentStringsParser adds localized names and descriptions of items
+type equipmentStringsParser struct {
+	*equipmentParser
+}
+
+func (p *equipmentStringsParser) DependsOn() []string {
+	return []string{p.name}
+}
+func (p *equipmentStringsParser) Exclusive() bool {
+	return false
+}
+func (p *equipmentStringsParser) Match(path string) bool {
+	return jsonStringsRegex.MatchString(path)
+}
+func (p *equipmentStringsParser) Parse(filePath string, r io.Reader) error {
+	locale, data, err := decodeJSONStrings(filePath, r)
+	if err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for id, item := range p.items {
+		addLocalized(p.names, id, locale, data, item.Key)
+		addLocalized(p.descriptions, id, locale, data, p.descriptionKeys[id])
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/cufee/aftermath-assets/types"
+	"github.com/matryer/is"
+	"golang.org/x/text/language"
+)
+
+const testConsumables = `<root>
+	<repairkit>
+		<id>1</id>
+		<userString>#artefacts:repairkit/name</userString>
+		<description>#artefacts:repairkit/descr</description>
+		<tags>repairkit trigger</tags>
+		<script>
+			<cooldown>60</cooldown>
+			<duration>4</duration>
+			<repairAll>true</repairAll>
+			<healthRestore>0.1</healthRestore>
+		</script>
+		<vehicleFilter>
+			<include><vehicle><tags>lightTank mediumTank</tags></vehicle></include>
+			<exclude><vehicle><tags>collectible</tags></vehicle></exclude>
+		</vehicleFilter>
+	</repairkit>
+</root>`
+
+func TestEquipment(t *testing.T) {
+	is := is.New(t)
+
+	fsys := fstest.MapFS{
+		"XML/item_defs/vehicles/ussr/list.xml":        &fstest.MapFile{Data: []byte(testVehicleList)},
+		"XML/item_defs/vehicles/consumables/list.xml": &fstest.MapFile{Data: []byte(testConsumables)},
+		"XML/item_defs/vehicles/provisions/list.xml":  &fstest.MapFile{Data: []byte("<root/>")},
+		"Strings/en.json":                             &fstest.MapFile{Data: []byte(`{"#artefacts:repairkit/name": "Repair Kit", "#artefacts:repairkit/descr": "Repairs modules"}`)},
+	}
+
+	assets := parseTestAssets(t, fsys, "consumables", "provisions", "vehicles")
+	consumables := readTestAsset[map[string]types.Equipment](t, assets, "consumables.json")
+	is.Equal(len(consumables), 1)
+
+	// ids are encoded as items without a nation, 1<<8 + 0xF<<4 + consumable kind
+	kit := consumables["507"]
+	is.Equal(kit.ID, "507")
+	is.Equal(kit.Name, "repairkit")
+	is.Equal(kit.Cooldown, 60.0)
+	is.Equal(kit.Duration, 4.0)
+	is.Equal(kit.Effects, map[string]float64{"healthRestore": 0.1})
+	is.Equal(kit.VehicleTags, []string{"lightTank", "mediumTank"})
+	is.Equal(kit.ExcludedVehicleTags, []string{"collectible"})
+	is.Equal(kit.LocalizedNames[language.English], "Repair Kit")
+	is.Equal(kit.LocalizedDescriptions[language.English], "Repairs modules")
+
+	provisions := readTestAsset[map[string]types.Equipment](t, assets, "provisions.json")
+	is.Equal(len(provisions), 0)
+}
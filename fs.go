@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+)
+
+// overlayFS combines multiple file systems into one, earlier layers shadow files from later layers
+type overlayFS struct {
+	layers []fs.FS
+}
+
+var _ fs.ReadDirFS = &overlayFS{}
+
+func newOverlayFS(layers ...fs.FS) *overlayFS {
+	return &overlayFS{layers: layers}
+}
+
+func (o *overlayFS) Open(name string) (fs.File, error) {
+	for _, layer := range o.layers {
+		f, err := layer.Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		return f, err
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (o *overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	var found bool
+	var merged []fs.DirEntry
+	seen := make(map[string]struct{})
+	for _, layer := range o.layers {
+		entries, err := fs.ReadDir(layer, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		found = true
+		for _, entry := range entries {
+			if _, ok := seen[entry.Name()]; ok {
+				continue
+			}
+			seen[entry.Name()] = struct{}{}
+			merged = append(merged, entry)
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	slices.SortFunc(merged, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return merged, nil
+}
+
+// filterFS hides files which do not match a filter, directories are always visible
+type filterFS struct {
+	fsys  fs.FS
+	match func(path string) bool
+}
+
+var _ fs.ReadDirFS = &filterFS{}
+
+func newFilterFS(fsys fs.FS, match func(path string) bool) *filterFS {
+	return &filterFS{fsys: fsys, match: match}
+}
+
+func (f *filterFS) Open(name string) (fs.File, error) {
+	file, err := f.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if !info.IsDir() && !f.match(name) {
+		file.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return file, nil
+}
+
+func (f *filterFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(f.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []fs.DirEntry
+	for _, entry := range entries {
+		if entry.IsDir() || f.match(path.Join(name, entry.Name())) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered, nil
+}
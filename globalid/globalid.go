@@ -0,0 +1,190 @@
+// Package globalid encodes and decodes global ids of game items, such as tank_id values returned by the Wargaming API.
+//
+// A global id packs a local id of an item within its nation, a nation id and an item kind:
+//
+//	(localID << 8) + (nationID << 4) + kind
+//
+// Items shared by all nations, such as consumables, use nation id 0xF and an empty nation name.
+package globalid
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrUnknownNation = errors.New("unknown nation")
+	ErrUnknownKind   = errors.New("unknown item kind")
+	ErrInvalidID     = errors.New("invalid id")
+)
+
+// MaxLocalID is the largest local id which can be encoded into a positive 32 bit id
+const MaxLocalID = 1<<23 - 1
+
+type Kind int
+
+const (
+	KindVehicle Kind = 1
+	KindChassis Kind = 2
+	KindTurret  Kind = 3
+	KindGun     Kind = 4
+	KindEngine  Kind = 5
+	KindShell   Kind = 10
+
+	KindConsumable Kind = 11
+	KindProvision  Kind = 12
+)
+
+// noNationID is the nation id of kinds which do not belong to a nation
+const noNationID = 0xF
+
+var nationlessKinds = map[Kind]bool{
+	KindConsumable: true,
+	KindProvision:  true,
+}
+
+var kindNames = map[Kind]string{
+	KindVehicle: "vehicle",
+	KindChassis: "chassis",
+	KindTurret:  "turret",
+	KindGun:     "gun",
+	KindEngine:  "engine",
+	KindShell:   "shell",
+
+	KindConsumable: "consumable",
+	KindProvision:  "provision",
+}
+
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// nations.json is the registry of nations, a nation id can never change once it is used by the game.
+// A new nation folder in item_defs/vehicles needs to be added to it before ids of its items can be encoded.
+//
+//go:embed nations.json
+var nationsRegistry []byte
+
+type nation struct {
+	ID  int    `json:"id"`
+	Key string `json:"key"`
+}
+
+var nations = mustLoadNations(nationsRegistry)
+
+func mustLoadNations(data []byte) map[string]nation {
+	var registry map[string]nation
+	if err := json.Unmarshal(data, &registry); err != nil {
+		panic("globalid: invalid nations registry: " + err.Error())
+	}
+
+	ids := make(map[int]string)
+	for name, n := range registry {
+		if n.ID < 0 || n.ID >= noNationID {
+			panic(fmt.Sprintf("globalid: nation %s id %d is out of range", name, n.ID))
+		}
+		if other, ok := ids[n.ID]; ok {
+			panic(fmt.Sprintf("globalid: nations %s and %s have the same id %d", name, other, n.ID))
+		}
+		ids[n.ID] = name
+	}
+	return registry
+}
+
+// Nations returns sorted names of all known nations
+func Nations() []string {
+	var names []string
+	for name := range nations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NationID returns an id of a nation, ok is false for unknown nations
+func NationID(nation string) (id int, ok bool) {
+	n, ok := nations[nation]
+	return n.ID, ok
+}
+
+// NationKey returns a localization key of a nation name in game Strings files
+func NationKey(nation string) (key string, ok bool) {
+	n, ok := nations[nation]
+	return n.Key, ok
+}
+
+// Nation returns a name of a nation by id, ok is false for unknown ids
+func Nation(id int) (nation string, ok bool) {
+	for name, n := range nations {
+		if n.ID == id {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Encode returns a global id of a vehicle
+func Encode(nation string, localID int) (int, error) {
+	return EncodeItem(KindVehicle, nation, localID)
+}
+
+// EncodeItem returns a global id of an item of any kind, such as a gun or a shell.
+// The nation of a consumable or a provision has to be empty.
+func EncodeItem(kind Kind, nation string, localID int) (int, error) {
+	if _, ok := kindNames[kind]; !ok {
+		return 0, fmt.Errorf("%w: %d", ErrUnknownKind, kind)
+	}
+	nationID := noNationID
+	if nationlessKinds[kind] {
+		if nation != "" {
+			return 0, fmt.Errorf("%w: %s does not belong to a nation, got %s", ErrUnknownNation, kind, nation)
+		}
+	} else {
+		n, ok := nations[nation]
+		if !ok {
+			return 0, fmt.Errorf("%w: %s", ErrUnknownNation, nation)
+		}
+		nationID = n.ID
+	}
+	if localID < 0 || localID > MaxLocalID {
+		return 0, fmt.Errorf("%w: local id %d is out of range", ErrInvalidID, localID)
+	}
+	return (localID << 8) + (nationID << 4) + int(kind), nil
+}
+
+// Decode returns a nation, a local id and a kind of an item encoded into a global id
+func Decode(id int) (nation string, localID int, kind Kind, err error) {
+	if err := Validate(id); err != nil {
+		return "", 0, 0, err
+	}
+	nation, _ = Nation((id >> 4) & 0xF)
+	return nation, id >> 8, Kind(id & 0xF), nil
+}
+
+// Validate returns an error when an id is not a valid global id
+func Validate(id int) error {
+	if id <= 0 || id>>8 > MaxLocalID {
+		return fmt.Errorf("%w: %d is out of range", ErrInvalidID, id)
+	}
+	kind := Kind(id & 0xF)
+	if kindNames[kind] == "" {
+		return fmt.Errorf("%w: %d", ErrUnknownKind, kind)
+	}
+	nationID := (id >> 4) & 0xF
+	if nationlessKinds[kind] {
+		if nationID != noNationID {
+			return fmt.Errorf("%w: %s does not belong to a nation, got %d", ErrUnknownNation, kind, nationID)
+		}
+		return nil
+	}
+	if _, ok := Nation(nationID); !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownNation, nationID)
+	}
+	return nil
+}
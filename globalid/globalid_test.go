@@ -0,0 +1,101 @@
+package globalid
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestVehicleIDs(t *testing.T) {
+	is := is.New(t)
+
+	data, err := os.ReadFile("../assets/vehicles.json")
+	is.NoErr(err)
+	var vehicles map[string]struct {
+		ID     string `json:"id"`
+		Nation string `json:"nation"`
+	}
+	is.NoErr(json.Unmarshal(data, &vehicles))
+	is.True(len(vehicles) > 0)
+
+	for _, vehicle := range vehicles {
+		t.Run(vehicle.ID, func(t *testing.T) {
+			is := is.New(t)
+
+			id, err := strconv.Atoi(vehicle.ID)
+			is.NoErr(err)
+
+			nation, localID, kind, err := Decode(id)
+			is.NoErr(err)
+			is.Equal(nation, vehicle.Nation)
+			is.Equal(kind, KindVehicle)
+
+			encoded, err := Encode(nation, localID)
+			is.NoErr(err)
+			is.Equal(encoded, id)
+		})
+	}
+}
+
+func TestCodec(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      int
+		nation  string
+		localID int
+		kind    Kind
+		err     error
+	}{
+		{name: "ussr vehicle", id: 1, nation: "ussr", localID: 0, kind: KindVehicle},
+		{name: "germany vehicle", id: 10001, nation: "germany", localID: 39, kind: KindVehicle},
+		{name: "european vehicle", id: 129, nation: "european", localID: 0, kind: KindVehicle},
+		{name: "gun", id: 260, nation: "ussr", localID: 1, kind: KindGun},
+		{name: "shell", id: 522, nation: "ussr", localID: 2, kind: KindShell},
+		{name: "consumable", id: 0x1fb, nation: "", localID: 1, kind: KindConsumable},
+		{name: "provision", id: 0x2fc, nation: "", localID: 2, kind: KindProvision},
+		{name: "consumable with a nation", id: 0x10b, err: ErrUnknownNation},
+		{name: "zero", id: 0, err: ErrInvalidID},
+		{name: "negative", id: -1, err: ErrInvalidID},
+		{name: "unknown kind", id: 0x10f, err: ErrUnknownKind},
+		{name: "unknown nation", id: 0x1f1, err: ErrUnknownNation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			nation, localID, kind, err := Decode(tt.id)
+			if tt.err != nil {
+				is.True(errors.Is(err, tt.err))
+				return
+			}
+			is.NoErr(err)
+			is.Equal(nation, tt.nation)
+			is.Equal(localID, tt.localID)
+			is.Equal(kind, tt.kind)
+
+			id, err := EncodeItem(kind, nation, localID)
+			is.NoErr(err)
+			is.Equal(id, tt.id)
+		})
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	is := is.New(t)
+
+	_, err := Encode("sweden", 1)
+	is.True(errors.Is(err, ErrUnknownNation))
+	_, err = Encode("ussr", -1)
+	is.True(errors.Is(err, ErrInvalidID))
+	_, err = Encode("ussr", MaxLocalID+1)
+	is.True(errors.Is(err, ErrInvalidID))
+	_, err = EncodeItem(Kind(7), "ussr", 1)
+	is.True(errors.Is(err, ErrUnknownKind))
+	_, err = EncodeItem(KindConsumable, "ussr", 1)
+	is.True(errors.Is(err, ErrUnknownNation))
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+// testParseConfig exports assets into a temporary directory of a test
+func testParseConfig(t *testing.T) parseConfig {
+	return parseConfig{assetsPath: t.TempDir()}
+}
+
+// parseTestAssets parses fsys and exports assets into a temporary directory, which is returned
+func parseTestAssets(t *testing.T, fsys fs.FS, names ...string) string {
+	is := is.New(t)
+	is.Helper()
+
+	config := testParseConfig(t)
+	err := parseAssets(context.Background(), newWorkerPool(2), fsys, names, config)
+	is.NoErr(err)
+	return config.assetsPath
+}
+
+// readTestAsset decodes a file exported into dir
+func readTestAsset[T any](t *testing.T, dir, file string) T {
+	is := is.New(t)
+	is.Helper()
+
+	f, err := os.Open(filepath.Join(dir, file))
+	is.NoErr(err)
+	defer f.Close()
+	value, err := decodeJSON[T](f)
+	is.NoErr(err)
+	return value
+}
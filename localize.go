@@ -1,7 +1,66 @@
 package main
 
+import (
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+)
+
+// jsonStringsRegex matches merged json Strings files, one file per locale
+var jsonStringsRegex = regexp.MustCompile("(^|/)Strings/.*.json")
+
+// decodeJSONStrings decodes a merged json Strings file, the locale is taken from the file name
+func decodeJSONStrings(filePath string, r io.Reader) (language.Tag, map[string]string, error) {
+	lang := strings.Split(path.Base(filePath), ".")[0]
+	locale, err := language.Parse(lang)
+	if err != nil {
+		return language.Tag{}, nil, errors.Wrap(err, "failed to get locale from a filename")
+	}
+
+	data, err := decodeJSON[map[string]string](r)
+	if err != nil {
+		return language.Tag{}, nil, err
+	}
+	return locale, data, nil
+}
+
 type LocalizationString struct {
 	Key   string `yaml:"key"`
 	Value string `yaml:"value"`
 	Notes string `yaml:"notes"`
 }
+
+// reduceLocalizedNames removes names matching the english name to reduce the size of exported files
+func reduceLocalizedNames(names map[language.Tag]string) map[language.Tag]string {
+	nameEnglish := names[language.English]
+	for tag, name := range names {
+		if name == nameEnglish && tag != language.English {
+			delete(names, tag)
+		}
+	}
+	return names
+}
+
+// addLocalized adds a localized value of key from data to values, keys missing from data are skipped
+func addLocalized(values map[string]map[language.Tag]string, id string, locale language.Tag, data map[string]string, key string) {
+	localized, ok := data[key]
+	if !ok || key == "" {
+		return
+	}
+	if values[id] == nil {
+		values[id] = make(map[language.Tag]string)
+	}
+	values[id][locale] = localized
+}
+
+// localizedOrEmpty makes entries without any strings export an empty object instead of null
+func localizedOrEmpty(values map[language.Tag]string) map[language.Tag]string {
+	if values == nil {
+		return make(map[language.Tag]string)
+	}
+	return values
+}
@@ -1,10 +1,22 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log/slog"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/alexflint/go-arg"
+	"github.com/cufee/aftermath-assets/dvpl"
+	"github.com/pkg/errors"
 
 	_ "github.com/joho/godotenv/autoload"
 )
@@ -21,10 +33,35 @@ var args struct {
 	SteamPassword      string `arg:"--password,env:DOWNLOADER_STEAM_PASSWORD" help:"steam account password" placeholder:"<password>"`
 	DownloaderFileList string `arg:"--file-list,env:DOWNLOADER_FILE_LIST" help:"path to filelist.txt" placeholder:"<path>"`
 
-	Decrypt     bool   `help:"decrypt downloaded files"`
-	DecryptPath string `arg:"--decrypt-path,env:DECRYPT_DIR_PATH" help:"path to a directory where decrypted files will be stored" placeholder:"<decrypted_path>"`
+	Decrypt        bool     `help:"decrypt downloaded files"`
+	DecryptPath    string   `arg:"--decrypt-path,env:DECRYPT_DIR_PATH" help:"path to a directory where decrypted files will be stored" placeholder:"<decrypted_path>"`
+	DecryptInclude []string `arg:"--include,separate" help:"decrypt only files matching a rule, rules use the DepotDownloader filelist.txt syntax and are relative to the dump path" placeholder:"<rule>"`
+	DecryptExclude []string `arg:"--exclude,separate" help:"do not decrypt files matching a rule" placeholder:"<rule>"`
+	DecryptRules   string   `arg:"--rules,env:DECRYPT_RULES_PATH" help:"path to a file with decrypt rules, one per line, exclude rules start with !" placeholder:"<path>"`
+	Passthrough    bool     `help:"copy files that are not dvpl encoded into the decrypt directory when they match the rules"`
 
-	Parse bool `help:"parse decrypted files into asset strings"`
+	Jobs       int    `arg:"--jobs,env:JOBS" help:"number of files processed in parallel, defaults to the number of CPUs" placeholder:"<n>"`
+	Force      bool   `help:"ignore the cache and process all files"`
+	Strict     bool   `help:"fail the run on any decrypt or parse error, including errors from non-exclusive parsers"`
+	ReportPath string `arg:"--report,env:REPORT_PATH" help:"path to a json report written at the end of every run, no report is written by default" placeholder:"<path>"`
+	LogLevel   string `arg:"--log-level,env:LOG_LEVEL" default:"info" help:"minimum log level, one of debug, info, warn, error" placeholder:"<level>"`
+	LogFormat  string `arg:"--log-format,env:LOG_FORMAT" default:"text" help:"log output format, one of text, json" placeholder:"<format>"`
+
+	DownloadTimeout time.Duration `arg:"--download-timeout,env:DOWNLOAD_TIMEOUT" default:"45m" help:"deadline for the download stage, 0 disables it" placeholder:"<duration>"`
+	DecryptTimeout  time.Duration `arg:"--decrypt-timeout,env:DECRYPT_TIMEOUT" default:"15m" help:"deadline for the decrypt stage, 0 disables it" placeholder:"<duration>"`
+	PackTimeout     time.Duration `arg:"--pack-timeout,env:PACK_TIMEOUT" default:"15m" help:"deadline for the pack stage, 0 disables it" placeholder:"<duration>"`
+	ParseTimeout    time.Duration `arg:"--parse-timeout,env:PARSE_TIMEOUT" default:"15m" help:"deadline for the parse stage, 0 disables it" placeholder:"<duration>"`
+
+	Parse        bool   `help:"parse decrypted files into asset strings"`
+	ParseDump    bool   `arg:"--parse-dump" help:"parse files directly from the depot dump instead of decrypted files, files are selected with decrypt rules"`
+	Only         string `help:"parse and export only these assets, comma separated" placeholder:"<names>"`
+	StringTables string `arg:"--string-tables,env:STRING_TABLES_PATH" help:"path to a string tables config replacing the default one, see string_tables.yaml" placeholder:"<path>"`
+	Plugins      string `arg:"--plugins,env:PLUGINS_PATH" help:"path to a config with external command parsers, each exported as an extra asset" placeholder:"<path>"`
+	Skip         string `help:"do not parse and export these assets, comma separated" placeholder:"<names>"`
+
+	Pack            bool   `help:"pack decrypted files back into dvpl files"`
+	PackPath        string `arg:"--pack-path,env:PACK_DIR_PATH" help:"path to a directory where packed files will be stored" placeholder:"<packed_path>"`
+	PackCompression string `arg:"--pack-compression" default:"lz4hc" help:"compression used for packed files, one of none, lz4, lz4hc" placeholder:"<type>"`
 
 	WargamingAppID string `arg:"--app-id,env:WARGAMING_APP_ID" help:"wargaming application id for api requests" placeholder:"<key>"`
 
@@ -33,93 +70,355 @@ var args struct {
 }
 
 func main() {
-	arg.MustParse(&args)
+	parser := arg.MustParse(&args)
+
+	logger, err := newLogger(os.Stderr, args.LogLevel, args.LogFormat)
+	if err != nil {
+		parser.Fail(err.Error())
+	}
+	slog.SetDefault(logger)
+
+	if args.StringTables != "" {
+		err := useStringTables(args.StringTables)
+		if err != nil {
+			parser.Fail(err.Error())
+		}
+	}
+	if args.Plugins != "" {
+		err := registerExecPlugins(args.Plugins)
+		if err != nil {
+			parser.Fail(err.Error())
+		}
+	}
+
+	// Stages stop cleanly on SIGINT/SIGTERM or once their deadline is exceeded
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	cdn := NewCDNClient(args.WargamingAppID)
+	pool := newWorkerPool(args.Jobs)
 
 	if args.Download {
-		var client *emailClient
-		if args.EmailEnabled {
-			c, err := newEmailClient(args.emailConfig)
-			if err != nil {
-				panic(err)
+		err := runStage(ctx, "download", args.DownloadTimeout, func(ctx context.Context) error {
+			var client *emailClient
+			if args.EmailEnabled {
+				c, err := newEmailClient(args.emailConfig)
+				if err != nil {
+					return err
+				}
+				client = c
 			}
-			client = c
+			return downloadAssetsFromSteam(ctx, client)
+		})
+		if err != nil {
+			exitWithSummary("download", err)
 		}
+	}
 
-		err := downloadAssetsFromSteam(client)
+	cache, err := loadCacheManifest(args.DecryptPath)
+	if err != nil {
+		panic(err)
+	}
+	if args.Force {
+		cache.Reset()
+	}
+
+	if args.Decrypt {
+		err := runStage(ctx, "decrypt", args.DecryptTimeout, func(ctx context.Context) error {
+			return decryptAssets(ctx, pool, cdn, cache)
+		})
 		if err != nil {
-			panic(err)
+			exitWithSummary("decrypt", err)
 		}
 	}
 
-	if args.Decrypt {
-		// Decrypt downloaded files
-		stringsPath := filepath.Join(args.DumpPath, "Data")
-		dir, err := os.ReadDir(stringsPath)
+	if args.Pack {
+		// Pack decrypted files back into the format used by the game client
+		err := runStage(ctx, "pack", args.PackTimeout, func(ctx context.Context) error {
+			return packDir(ctx, args.DecryptPath, args.PackPath, args.PackCompression)
+		})
 		if err != nil {
-			panic(err)
+			exitWithSummary("pack", err)
 		}
-		err = decryptDir(stringsPath, dir, args.DecryptPath)
+	}
+
+	if args.Parse {
+		err := runStage(ctx, "parse", args.ParseTimeout, func(ctx context.Context) error {
+			return parseInputs(ctx, pool, cdn, cache)
+		})
 		if err != nil {
-			panic(err)
+			exitWithSummary("parse", err)
 		}
+	}
+
+	err = report.Save(args.ReportPath, args.Strict, true)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// decryptAssets decrypts downloaded files and merges missing localization strings
+func decryptAssets(ctx context.Context, pool *workerPool, cdn *wargamingCDNClient, cache *cacheManifest) error {
+	rules, err := decryptRules()
+	if err != nil {
+		return err
+	}
+
+	d := &decrypter{pool: pool, cache: cache, rules: rules, rulesRoot: args.DumpPath, passthrough: args.Passthrough}
+	err = d.decryptDir(ctx, filepath.Join(args.DumpPath, "Data"), args.DecryptPath)
+	loggerFrom(ctx).Info("decrypted files", "files", &d.stats)
+	if err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	err = cache.Prune()
+	if err != nil {
+		return err
+	}
 
-		err = mergeMissingStrings(cdn, args.DecryptPath)
+	err = cache.Save()
+	if err != nil {
+		return err
+	}
+
+	return mergeMissingStrings(ctx, cdn, os.DirFS(args.DecryptPath), args.DecryptPath)
+}
+
+// decryptRules returns rules from flags and the rules file, default rules are used when there are no include rules
+func decryptRules() (fileRules, error) {
+	include, exclude := args.DecryptInclude, args.DecryptExclude
+	if args.DecryptRules != "" {
+		fileInclude, fileExclude, err := readRulesFile(args.DecryptRules)
 		if err != nil {
-			panic(err)
+			return fileRules{}, err
 		}
+		include = append(include, fileInclude...)
+		exclude = append(exclude, fileExclude...)
+	}
+	if len(include) == 0 {
+		include = defaultDecryptRules
+	}
+	return newFileRules(include, exclude)
+}
+
+// parseInputs selects parse inputs and parses them, unless they did not change since the last run
+func parseInputs(ctx context.Context, pool *workerPool, cdn *wargamingCDNClient, cache *cacheManifest) error {
+	names, err := selectAssets(args.Only, args.Skip)
+	if err != nil {
+		return err
+	}
+	config := parseConfig{
+		assetsPath: args.AssetsPath,
+		strict:     args.Strict,
+		configs:    []string{args.StringTables, args.Plugins},
 	}
 
-	if args.Parse {
-		err := os.MkdirAll(args.DecryptPath, os.ModeDir)
+	err = os.MkdirAll(args.DecryptPath, os.ModeDir)
+	if err != nil {
+		return err
+	}
+
+	var input fs.FS = os.DirFS(args.DecryptPath)
+	var inputDigest string
+	if args.ParseDump {
+		// Files are decrypted on read, only the merged strings need to be written to disk
+		stringsDir, err := os.MkdirTemp("", "aftermath-strings-")
 		if err != nil {
-			panic(err)
+			return err
 		}
+		defer os.RemoveAll(stringsDir)
 
-		// Init parsing functions
-		maps := newMapParser()
-		version := newVersionParser()
-		vehicles := newVehiclesParser()
-		battleTypes := newBattleTypeParser()
+		// the same files are selected as in the decrypt stage, rules are relative to the dump path
+		rules, err := decryptRules()
+		if err != nil {
+			return err
+		}
+		dump := newFilterFS(os.DirFS(filepath.Join(args.DumpPath, "Data")), func(path string) bool {
+			return rules.Selected("Data/"+path, args.Passthrough)
+		})
 
-		// Due to how the parsing code is written, we will need to loop over the files twice
-		// first loop parses yaml/xml files to extract identifier
-		// second loop will parse strings yaml files to create localized dicts
-		{
-			parser, err := newParser(args.DecryptPath, maps.Maps(), vehicles.Items(), battleTypes, version)
-			if err != nil {
-				panic(err)
-			}
-			if err := parser.Parse(); err != nil {
-				panic(err)
-			}
+		err = mergeMissingStrings(ctx, cdn, dvpl.NewFS(dump), stringsDir)
+		if err != nil {
+			return err
 		}
-		{
-			parser, err := newParser(args.DecryptPath, maps.Strings(), vehicles.Strings())
-			if err != nil {
-				panic(err)
-			}
-			if err := parser.Parse(); err != nil {
-				panic(err)
-			}
+		input = newOverlayFS(dvpl.NewFS(dump), os.DirFS(stringsDir))
+
+		// encoded files are hashed as is, decoding every file would defeat the point of parsing the dump
+		dumpDigest, err := cache.InputDigest(dump, "")
+		if err != nil {
+			return err
+		}
+		stringsDigest, err := cache.InputDigest(os.DirFS(stringsDir), "")
+		if err != nil {
+			return err
 		}
+		inputDigest = dumpDigest + ":" + stringsDigest
+	} else {
+		inputDigest, err = cache.InputDigest(input, args.DecryptPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	digest, err := parseDigest(inputDigest, names, config)
+	if err != nil {
+		loggerFrom(ctx).Warn("failed to identify parse inputs, the cache is not used", "error", err)
+	}
+	if digest != "" && digest == cache.ParsedInputs && exportsExist(names, config) {
+		loggerFrom(ctx).Info("no input changes, skipping parsing")
+		return nil
+	}
+
+	err = parseAssets(ctx, pool, input, names, config)
+	if err != nil {
+		return err
+	}
+
+	cache.ParsedInputs = digest
+	return cache.Save()
+}
+
+// parseConfig configures parsing and exporting of assets, it is set from flags
+type parseConfig struct {
+	// assetsPath is the directory assets are exported to
+	assetsPath string
+	// strict runs fail an asset on an error from any of its parsers, not only from an exclusive one
+	strict bool
+	// configs are paths of config files changing parsed assets, such as string tables and plugins, empty paths are ignored
+	configs []string
+}
 
-		err = maps.Export(filepath.Join(args.AssetsPath, "maps.json"))
+// parseDigest identifies a parse run by its inputs, the binary, configs, selected assets and the output path
+func parseDigest(inputDigest string, names []string, config parseConfig) (string, error) {
+	version, err := buildVersion()
+	if err != nil {
+		return "", err
+	}
+	assetsPath, err := filepath.Abs(config.assetsPath)
+	if err != nil {
+		return "", err
+	}
+
+	digest := sha256.New()
+	fmt.Fprintf(digest, "build %s\n", version)
+	fmt.Fprintf(digest, "assets %s\n", strings.Join(names, ","))
+	fmt.Fprintf(digest, "output %s\n", assetsPath)
+	for _, path := range config.configs {
+		if path == "" {
+			continue
+		}
+		hash, err := hashFile(path)
 		if err != nil {
-			panic(err)
+			return "", err
 		}
-		err = vehicles.Export(filepath.Join(args.AssetsPath, "vehicles.json"))
+		fmt.Fprintf(digest, "config %s %s\n", path, hash)
+	}
+	fmt.Fprintf(digest, "inputs %s\n", inputDigest)
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+// exportsExist checks that files of all selected assets are still in the assets directory
+func exportsExist(names []string, config parseConfig) bool {
+	for _, name := range names {
+		_, err := os.Stat(filepath.Join(config.assetsPath, assetRegistry[name]().File))
 		if err != nil {
-			panic(err)
+			return false
 		}
-		err = version.Export(filepath.Join(args.AssetsPath, "metadata.json"))
+	}
+	return true
+}
+
+// runStage runs a pipeline stage with a deadline, an error caused by a cancelled context names the stage
+func runStage(ctx context.Context, stage string, timeout time.Duration, fn func(ctx context.Context) error) error {
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeoutCause(ctx, timeout, fmt.Errorf("%s stage did not finish in %s", stage, timeout))
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	logger := loggerFrom(ctx).With("stage", stage)
+	ctx = withLogger(ctx, logger)
+
+	logger.Info("stage started")
+	startedAt := time.Now()
+	err := fn(ctx)
+	if ctx.Err() != nil {
+		err = joinErrors(fmt.Errorf("%s stage stopped: %w", stage, context.Cause(ctx)), err)
+	}
+
+	duration := time.Since(startedAt)
+	report.Stage(stage, duration, err)
+	if err == nil {
+		logger.Info("stage finished", "duration", duration)
+	}
+	return err
+}
+
+// parseAssets parses all files from input and exports selected assets.
+// An asset with a failed parser or a failed dependency is not exported, other assets are exported as usual.
+// In a strict run an error from any parser fails its asset, otherwise only errors from exclusive parsers do.
+func parseAssets(ctx context.Context, pool *workerPool, input fs.FS, names []string, config parseConfig) error {
+	// ids of items from a nation missing in the registry would collide with other nations
+	err := checkNations(input)
+	if err != nil {
+		return err
+	}
+
+	assets := make(map[string]*registeredAsset)
+	for _, name := range names {
+		a, err := newRegisteredAsset(name)
 		if err != nil {
-			panic(err)
+			return err
 		}
-		err = battleTypes.Export(filepath.Join(args.AssetsPath, "game_modes.json"))
+		assets[name] = a
+	}
+
+	// Parsers run in phases, a parser can use results of all parsers it depends on
+	phases, err := parsePhases(assets, config.strict)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, parsers := range phases {
+		parser, err := newParser(pool, input, parsers...)
+		if err != nil {
+			return err
+		}
+		parser.strict = config.strict
+		if err := parser.Parse(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return joinErrors(errs...)
+	}
+	failDependents(ctx, assets)
+
+	for _, name := range names {
+		a := assets[name]
+		if a.failed.Load() {
+			errs = append(errs, errors.New(a.name+": not exported, parsing failed"))
+			continue
+		}
+		if skipper, ok := a.Exporter.(exportSkipper); ok {
+			if reason := skipper.SkipExport(); reason != nil {
+				loggerFrom(ctx).Warn("asset not exported", "asset", a.name, "error", reason)
+				report.Warn(a.File, errors.Wrap(reason, "not exported"))
+				continue
+			}
+		}
+		entities, err := a.Exporter.Export(filepath.Join(config.assetsPath, a.File))
+		report.Exported(a.name, a.File, entities, err)
 		if err != nil {
-			panic(err)
+			errs = append(errs, errors.Wrap(err, a.name+": failed to export"))
 		}
 	}
+
+	return joinErrors(errs...)
 }
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestRunStageDeadline(t *testing.T) {
+	is := is.New(t)
+
+	err := runStage(context.Background(), "parse", time.Millisecond*10, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "parse stage did not finish in 10ms"))
+
+	err = runStage(context.Background(), "parse", 0, func(ctx context.Context) error {
+		return nil
+	})
+	is.NoErr(err)
+}
+
+func TestStrictParser(t *testing.T) {
+	is := is.New(t)
+
+	fsys := fstest.MapFS{
+		"Strings/en.yaml": &fstest.MapFile{Data: []byte("invalid: [yaml")},
+	}
+
+	tables, err := decodeStringTables(defaultStringTables)
+	is.NoErr(err)
+
+	parser, err := newParser(newWorkerPool(1), fsys, newStringTableParser(tables[0]))
+	is.NoErr(err)
+	is.NoErr(parser.Parse(context.Background()))
+
+	parser.strict = true
+	is.True(parser.Parse(context.Background()) != nil)
+}
+
+func TestStageLogger(t *testing.T) {
+	is := is.New(t)
+
+	_, err := newLogger(nil, "verbose", "text")
+	is.True(err != nil)
+	_, err = newLogger(nil, "info", "xml")
+	is.True(err != nil)
+
+	var buf bytes.Buffer
+	logger, err := newLogger(&buf, "debug", "json")
+	is.NoErr(err)
+
+	ctx := withLogger(context.Background(), logger)
+	err = runStage(ctx, "decrypt", 0, func(ctx context.Context) error {
+		loggerFrom(ctx).Debug("decrypting", "path", "Data/a.txt.dvpl")
+		return nil
+	})
+	is.NoErr(err)
+
+	var records []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var record map[string]any
+		is.NoErr(json.Unmarshal(line, &record))
+		records = append(records, record)
+	}
+	is.Equal(len(records), 3) // started, decrypting, finished
+	for _, record := range records {
+		is.Equal(record["stage"], "decrypt")
+	}
+	is.Equal(records[1]["path"], "Data/a.txt.dvpl")
+}
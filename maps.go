@@ -17,6 +17,13 @@ import (
 	"golang.org/x/text/language"
 )
 
+func init() {
+	registerAsset("maps", func() asset {
+		maps := newMapParser()
+		return asset{File: "maps.json", Parsers: map[string]parseFunc{"maps": maps.Maps(), "maps.strings": maps.Strings()}, Exporter: maps}
+	})
+}
+
 type mapsEntry struct {
 	LocalID         int    `yaml:"id"`
 	Key             string `yaml:"localName"`
@@ -30,10 +37,10 @@ type mapParser struct {
 	localizedNames map[string]map[language.Tag]string
 }
 
-func (p *mapParser) Export(filePath string) error {
+func (p *mapParser) Export(filePath string) (int, error) {
 	err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
 	if err != nil {
-		return errors.Wrap(err, "failed to create path")
+		return 0, errors.Wrap(err, "failed to create path")
 	}
 
 	maps := make(map[string]types.Map)
@@ -58,12 +65,12 @@ func (p *mapParser) Export(filePath string) error {
 
 	f, err := os.Create(filePath)
 	if err != nil {
-		return errors.Wrap(err, "failed to create file")
+		return 0, errors.Wrap(err, "failed to create file")
 	}
 
 	e := json.NewEncoder(f)
 	e.SetIndent("", "  ")
-	return e.Encode(mapsSorted)
+	return len(mapsSorted), e.Encode(mapsSorted)
 }
 
 func (p *mapParser) Strings() *mapStringsParser {
@@ -88,6 +95,9 @@ type mapStringsParser struct {
 	localizedNames map[string]map[language.Tag]string
 }
 
+func (p *mapStringsParser) DependsOn() []string {
+	return []string{"maps"}
+}
 func (p *mapStringsParser) Exclusive() bool {
 	return false
 }
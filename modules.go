@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cufee/aftermath-assets/globalid"
+	"github.com/cufee/aftermath-assets/types"
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+)
+
+func init() {
+	registerAsset("modules", func() asset {
+		modules := newModulesParser()
+		return asset{File: "modules.json", Parsers: map[string]parseFunc{"modules": modules.Components(), "modules.strings": modules.Strings()}, Exporter: modules}
+	})
+}
+
+// componentsRegex matches shared module definitions in XML/item_defs/vehicles/<nation>/components/<type>.xml
+var componentsRegex = regexp.MustCompile(`(^|/)XML/item_defs/vehicles/([^/]+)/components/(guns|turrets|engines|chassis)\.xml$`)
+
+// moduleKinds are keyed by the name of a components file
+var moduleKinds = map[string]globalid.Kind{
+	"chassis": globalid.KindChassis,
+	"turrets": globalid.KindTurret,
+	"guns":    globalid.KindGun,
+	"engines": globalid.KindEngine,
+}
+
+type modulesParser struct {
+	moduleNames map[string]map[language.Tag]string
+	modules     map[string]types.Module
+	// moduleIDs are keyed by nation, module type and component name, <nation>/<type>/<name>
+	moduleIDs map[string]string
+	lock      *sync.Mutex
+}
+
+func newModulesParser() *modulesParser {
+	return &modulesParser{
+		lock:        &sync.Mutex{},
+		modules:     make(map[string]types.Module),
+		moduleIDs:   make(map[string]string),
+		moduleNames: make(map[string]map[language.Tag]string),
+	}
+}
+
+// moduleID returns a global id of a shared module of a type, such as gun or engine
+func (p *modulesParser) moduleID(nation, moduleType, name string) (string, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	id, ok := p.moduleIDs[nation+"/"+moduleType+"/"+name]
+	return id, ok
+}
+
+// module returns a shared module of a type by its component name
+func (p *modulesParser) module(nation, moduleType, name string) (types.Module, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	id, ok := p.moduleIDs[nation+"/"+moduleType+"/"+name]
+	if !ok {
+		return types.Module{}, false
+	}
+	return p.modules[id], true
+}
+
+func (p *modulesParser) Components() *moduleComponentsParser {
+	return &moduleComponentsParser{p}
+}
+func (p *modulesParser) Strings() *moduleStringsParser {
+	return &moduleStringsParser{p}
+}
+func (p *modulesParser) Export(filePath string) (int, error) {
+	err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create path")
+	}
+
+	modules := make(map[string]types.Module)
+	for id, module := range p.modules {
+		module.LocalizedNames = reduceLocalizedNames(localizedOrEmpty(p.moduleNames[id]))
+		modules[id] = module
+	}
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create file")
+	}
+	defer f.Close()
+
+	e := json.NewEncoder(f)
+	e.SetIndent("", "  ")
+	return len(modules), e.Encode(modules)
+}
+
+// moduleComponentsParser parses shared modules, each file lists module ids under <ids> and definitions under <shared>
+type moduleComponentsParser struct {
+	*modulesParser
+}
+
+func (p *moduleComponentsParser) Exclusive() bool {
+	return false
+}
+func (p *moduleComponentsParser) Match(path string) bool {
+	match := componentsRegex.FindStringSubmatch(path)
+	if match == nil {
+		return false
+	}
+	_, ok := globalid.NationID(match[2])
+	return ok
+}
+func (p *moduleComponentsParser) Parse(filePath string, r io.Reader) error {
+	match := componentsRegex.FindStringSubmatch(filePath)
+	nation, kind := match[2], moduleKinds[match[3]]
+
+	root, err := decodeXMLTree(r)
+	if err != nil {
+		return err
+	}
+
+	ids := make(map[string]int)
+	if node := root.Child("ids"); node != nil {
+		for _, child := range node.Children {
+			ids[child.Name], _ = strconv.Atoi(strings.TrimSpace(child.Text))
+		}
+	}
+
+	definitions := root.Child("shared")
+	if definitions == nil {
+		return errors.New("missing shared module definitions")
+	}
+
+	var modules []types.Module
+	for _, node := range definitions.Children {
+		localID, ok := ids[node.Name]
+		if !ok {
+			if node.Value("id") == "" {
+				return errors.New("missing id for module " + node.Name)
+			}
+			localID = node.Int("id")
+		}
+
+		id, err := globalid.EncodeItem(kind, nation, localID)
+		if err != nil {
+			return errors.Wrap(err, "invalid id for module "+node.Name)
+		}
+
+		module := types.Module{
+			ID:     fmt.Sprint(id),
+			Key:    node.Value("userString"),
+			Type:   kind.String(),
+			Name:   node.Name,
+			Nation: nation,
+
+			Tier:     node.Int("level"),
+			Weight:   node.Float("weight"),
+			Price:    itemPrice(node),
+			Traverse: node.Float("rotationSpeed"),
+		}
+		switch kind {
+		case globalid.KindGun:
+			module.ReloadTime = node.Float("reloadTime")
+			module.AimTime = node.Float("aimingTime")
+			module.Dispersion = node.Float("shotDispersionRadius")
+		case globalid.KindEngine:
+			module.Power = node.Float("power")
+		}
+		modules = append(modules, module)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, module := range modules {
+		p.modules[module.ID] = module
+		p.moduleIDs[nation+"/"+module.Type+"/"+module.Name] = module.ID
+	}
+	return nil
+}
+
+// moduleStringsParser looks up names of modules by their userString keys
+type moduleStringsParser struct {
+	*modulesParser
+}
+
+func (p *moduleStringsParser) DependsOn() []string {
+	return []string{"modules"}
+}
+func (p *moduleStringsParser) Exclusive() bool {
+	return false
+}
+func (p *moduleStringsParser) Match(path string) bool {
+	return jsonStringsRegex.MatchString(path)
+}
+func (p *moduleStringsParser) Parse(filePath string, r io.Reader) error {
+	locale, data, err := decodeJSONStrings(filePath, r)
+	if err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for id, module := range p.modules {
+		addLocalized(p.moduleNames, id, locale, data, module.Key)
+	}
+
+	return nil
+}
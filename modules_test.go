@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/cufee/aftermath-assets/types"
+	"github.com/matryer/is"
+	"golang.org/x/text/language"
+)
+
+const testGuns = `<root>
+	<ids>
+		<_76mm_L-11>1</_76mm_L-11>
+	</ids>
+	<shared>
+		<_76mm_L-11>
+			<userString>#ussr_vehicles:_76mm_L-11</userString>
+			<level>4</level>
+			<price>12000<credits/></price>
+			<weight>1270</weight>
+			<reloadTime>3.8</reloadTime>
+			<aimingTime>2.3</aimingTime>
+			<shotDispersionRadius>0.46</shotDispersionRadius>
+			<rotationSpeed>40</rotationSpeed>
+		</_76mm_L-11>
+	</shared>
+</root>`
+
+const testEngines = `<root>
+	<shared>
+		<V-2>
+			<id>3</id>
+			<userString>#ussr_vehicles:V-2</userString>
+			<level>6</level>
+			<power>500</power>
+		</V-2>
+	</shared>
+</root>`
+
+func TestModules(t *testing.T) {
+	is := is.New(t)
+
+	fsys := fstest.MapFS{
+		"XML/item_defs/vehicles/ussr/components/guns.xml":    &fstest.MapFile{Data: []byte(testGuns)},
+		"XML/item_defs/vehicles/ussr/components/engines.xml": &fstest.MapFile{Data: []byte(testEngines)},
+		"XML/item_defs/vehicles/ussr/components/shells.xml":  &fstest.MapFile{Data: []byte("<root/>")},
+		"Strings/en.json": &fstest.MapFile{Data: []byte(`{"#ussr_vehicles:_76mm_L-11": "76 mm L-11"}`)},
+	}
+
+	modules := readTestAsset[map[string]types.Module](t, parseTestAssets(t, fsys, "modules"), "modules.json")
+	is.Equal(len(modules), 2)
+
+	gun := modules["260"]
+	is.Equal(gun.Name, "_76mm_L-11")
+	is.Equal(gun.Type, "gun")
+	is.Equal(gun.Price, types.Price{Amount: 12000, Currency: "credits"})
+	is.Equal(gun.ReloadTime, 3.8)
+	is.Equal(gun.Traverse, 40.0)
+	is.Equal(gun.LocalizedNames[language.English], "76 mm L-11")
+
+	engine := modules["773"]
+	is.Equal(engine.Name, "V-2")
+	is.Equal(engine.Power, 500.0)
+	is.True(engine.LocalizedNames != nil) // exported as an empty object
+}
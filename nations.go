@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/cufee/aftermath-assets/globalid"
+	"github.com/cufee/aftermath-assets/types"
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+)
+
+func init() {
+	registerAsset("nations", func() asset {
+		nations := newNationsParser()
+		return asset{File: "nations.json", Parsers: map[string]parseFunc{"nations": nations.Lists(), "nations.strings": nations.Strings()}, Exporter: nations}
+	})
+}
+
+const vehiclesDir = "XML/item_defs/vehicles"
+
+var nationListRegex = regexp.MustCompile(`(^|/)XML/item_defs/vehicles/([^/]+)/list\.xml$`)
+
+// nationFolders returns names of folders in XML/item_defs/vehicles with a list of vehicles, a missing directory has no nations
+func nationFolders(fsys fs.FS) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, vehiclesDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var nations []string
+	for _, entry := range entries {
+		if _, equipment := equipmentLists[entry.Name()]; !entry.IsDir() || equipment {
+			continue
+		}
+		if _, err := fs.Stat(fsys, path.Join(vehiclesDir, entry.Name(), "list.xml")); err != nil {
+			continue
+		}
+		nations = append(nations, entry.Name())
+	}
+	return nations, nil
+}
+
+// checkNations returns an error for every nation folder missing from the nations registry,
+// ids of items from an unknown nation cannot be encoded
+func checkNations(fsys fs.FS) error {
+	nations, err := nationFolders(fsys)
+	if err != nil {
+		return errors.Wrap(err, "failed to find nations")
+	}
+
+	var errs []error
+	for _, nation := range nations {
+		if _, ok := globalid.NationID(nation); !ok {
+			errs = append(errs, errors.Errorf("nation %s is not in the nations registry, add it to globalid/nations.json", nation))
+		}
+	}
+	return joinErrors(errs...)
+}
+
+type nationsParser struct {
+	nationNames map[string]map[language.Tag]string
+	nations     map[string]types.Nation
+	lock        *sync.Mutex
+}
+
+func newNationsParser() *nationsParser {
+	return &nationsParser{
+		lock:        &sync.Mutex{},
+		nations:     make(map[string]types.Nation),
+		nationNames: make(map[string]map[language.Tag]string),
+	}
+}
+
+func (p *nationsParser) Lists() *nationListsParser {
+	return &nationListsParser{p}
+}
+func (p *nationsParser) Strings() *nationStringsParser {
+	return &nationStringsParser{p}
+}
+func (p *nationsParser) Export(filePath string) (int, error) {
+	err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create path")
+	}
+
+	nations := make(map[string]types.Nation)
+	for name, nation := range p.nations {
+		nation.LocalizedNames = reduceLocalizedNames(localizedOrEmpty(p.nationNames[name]))
+		nations[name] = nation
+	}
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create file")
+	}
+	defer f.Close()
+
+	e := json.NewEncoder(f)
+	e.SetIndent("", "  ")
+	return len(nations), e.Encode(nations)
+}
+
+// nationListsParser discovers nations from folders with a list of vehicles
+type nationListsParser struct {
+	*nationsParser
+}
+
+func (p *nationListsParser) Exclusive() bool {
+	return false
+}
+func (p *nationListsParser) Match(path string) bool {
+	match := nationListRegex.FindStringSubmatch(path)
+	if match == nil {
+		return false
+	}
+	_, equipment := equipmentLists[match[2]]
+	return !equipment
+}
+func (p *nationListsParser) Parse(path string, r io.Reader) error {
+	name := nationListRegex.FindStringSubmatch(path)[2]
+	id, ok := globalid.NationID(name)
+	if !ok {
+		return errors.Errorf("nation %s is not in the nations registry", name)
+	}
+	key, _ := globalid.NationKey(name)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.nations[name] = types.Nation{ID: id, Key: key, Name: name}
+	return nil
+}
+
+// nationStringsParser adds localized nation names, keys come from the nations registry
+type nationStringsParser struct {
+	*nationsParser
+}
+
+func (p *nationStringsParser) DependsOn() []string {
+	return []string{"nations"}
+}
+func (p *nationStringsParser) Exclusive() bool {
+	return false
+}
+func (p *nationStringsParser) Match(path string) bool {
+	return jsonStringsRegex.MatchString(path)
+}
+func (p *nationStringsParser) Parse(filePath string, r io.Reader) error {
+	locale, data, err := decodeJSONStrings(filePath, r)
+	if err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for name, nation := range p.nations {
+		addLocalized(p.nationNames, name, locale, data, nation.Key)
+	}
+	return nil
+}
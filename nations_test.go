@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/cufee/aftermath-assets/types"
+	"github.com/matryer/is"
+	"golang.org/x/text/language"
+)
+
+func TestNations(t *testing.T) {
+	is := is.New(t)
+
+	fsys := fstest.MapFS{
+		"XML/item_defs/vehicles/ussr/list.xml":        &fstest.MapFile{Data: []byte(testVehicleList)},
+		"XML/item_defs/vehicles/consumables/list.xml": &fstest.MapFile{Data: []byte("<root/>")},
+		"XML/item_defs/vehicles/common/crew.xml":      &fstest.MapFile{Data: []byte("<root/>")},
+		"Strings/en.json":                             &fstest.MapFile{Data: []byte(`{"#nations:ussr": "U.S.S.R."}`)},
+	}
+
+	nations := readTestAsset[map[string]types.Nation](t, parseTestAssets(t, fsys, "nations", "vehicles"), "nations.json")
+	is.Equal(len(nations), 1)
+	is.Equal(nations["ussr"].ID, 0)
+	is.Equal(nations["ussr"].LocalizedNames[language.English], "U.S.S.R.")
+
+	// a nation missing from the registry fails the run before any asset is exported
+	config := testParseConfig(t)
+	fsys["XML/item_defs/vehicles/sweden/list.xml"] = &fstest.MapFile{Data: []byte("<root/>")}
+	err := parseAssets(context.Background(), newWorkerPool(2), fsys, []string{"nations"}, config)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "nation sweden is not in the nations registry"))
+	_, err = os.Stat(filepath.Join(config.assetsPath, "nations.json"))
+	is.True(os.IsNotExist(err))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cufee/aftermath-assets/dvpl"
+	"github.com/pkg/errors"
+)
+
+var packCompressionTypes = map[string]uint32{
+	"none":  dvpl.CompressionNone,
+	"lz4":   dvpl.CompressionLZ4,
+	"lz4hc": dvpl.CompressionLZ4HC,
+}
+
+// packDir walks a directory tree and writes every file into outDir as a .dvpl file, keeping the relative layout
+func packDir(ctx context.Context, path string, outDir string, compression string) error {
+	compressType, ok := packCompressionTypes[compression]
+	if !ok {
+		return errors.New("invalid compression type " + compression)
+	}
+
+	return filepath.WalkDir(path, func(entryPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(path, entryPath)
+		if err != nil {
+			return err
+		}
+		if rel == cacheManifestName {
+			// the cache manifest is a part of the decrypt directory, but not of the game files
+			return nil
+		}
+		err = packFile(ctx, entryPath, filepath.Join(outDir, filepath.Dir(rel)), compressType)
+		if err != nil {
+			return errors.Wrap(err, "failed to pack "+entryPath)
+		}
+		return nil
+	})
+}
+
+func packFile(ctx context.Context, path string, outDir string, compressType uint32) error {
+	if strings.HasSuffix(path, ".dvpl") {
+		return nil
+	}
+	loggerFrom(ctx).Debug("packing", "path", path)
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(outDir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := dvpl.Encode(raw, compressType)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(outDir, filepath.Base(path)+".dvpl"), encrypted, os.ModePerm)
+}
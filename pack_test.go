@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cufee/aftermath-assets/dvpl"
+	"github.com/matryer/is"
+)
+
+func TestPackDir(t *testing.T) {
+	is := is.New(t)
+
+	in := t.TempDir()
+	out := t.TempDir()
+
+	content := bytes.Repeat([]byte("en: English\n"), 64)
+	is.NoErr(os.MkdirAll(filepath.Join(in, "Strings"), os.ModePerm))
+	is.NoErr(os.WriteFile(filepath.Join(in, "Strings", "en.yaml"), content, os.ModePerm))
+
+	cache, err := loadCacheManifest(in)
+	is.NoErr(err)
+	is.NoErr(cache.Save())
+
+	is.NoErr(packDir(context.Background(), in, out, "lz4"))
+
+	// the cache manifest of a decrypt directory is not packed
+	_, err = os.Stat(filepath.Join(out, cacheManifestName+".dvpl"))
+	is.True(errors.Is(err, fs.ErrNotExist))
+
+	packed, err := os.ReadFile(filepath.Join(out, "Strings", "en.yaml.dvpl"))
+	is.NoErr(err)
+
+	decrypted, err := dvpl.Decode(packed)
+	is.NoErr(err)
+	is.Equal(decrypted, content)
+
+	decryptedDir := t.TempDir()
+	d := &decrypter{pool: newWorkerPool(1), rulesRoot: out}
+	is.NoErr(d.decryptFile(context.Background(), filepath.Join(out, "Strings", "en.yaml.dvpl"), decryptedDir))
+
+	decrypted, err = os.ReadFile(filepath.Join(decryptedDir, "en.yaml"))
+	is.NoErr(err)
+	is.Equal(decrypted, content)
+}
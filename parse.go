@@ -2,11 +2,10 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"fmt"
 	"io"
-	"log"
-	"os"
-	"path/filepath"
-	"sync"
+	"io/fs"
 
 	"github.com/pkg/errors"
 )
@@ -14,77 +13,100 @@ import (
 type parseFunc interface {
 	Parse(path string, r io.Reader) error
 
+	// Exclusive parsers own files they match, an error fails the file even when the run is not strict
 	Exclusive() bool
 	Match(path string) bool
 }
 
-type parser struct {
-	inputPath string
-	parsers   []parseFunc
+// contextParseFunc is implemented by parsers which need to stop when the parse stage is cancelled
+type contextParseFunc interface {
+	ParseContext(ctx context.Context, path string, r io.Reader) error
 }
 
-func newParser(in string, parsers ...parseFunc) (*parser, error) {
-	if len(parsers) < 1 {
-		return nil, errors.New("parsers slice cannot be empty")
+// parseWithContext calls ParseContext on parsers implementing it and Parse otherwise
+func parseWithContext(ctx context.Context, parser parseFunc, path string, r io.Reader) error {
+	if p, ok := parser.(contextParseFunc); ok {
+		return p.ParseContext(ctx, path, r)
 	}
-	return &parser{inputPath: in, parsers: parsers}, nil
+	return parser.Parse(path, r)
 }
 
-func (p *parser) Parse() error {
-	return p.parseDir(p.inputPath)
+type parser struct {
+	fsys    fs.FS
+	pool    *workerPool
+	parsers []parseFunc
+	// strict parsers fail a file when any parser returns an error, not only an exclusive one
+	strict bool
 }
 
-func (p *parser) parseDir(path string) error {
-	dir, err := os.ReadDir(path)
-	if err != nil {
-		return errors.Wrap(err, "failed to read a directory")
+// parserName names a parser in logs, registered parsers are named after their asset
+func parserName(p parseFunc) string {
+	if named, ok := p.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return fmt.Sprintf("%T", p)
+}
+
+func newParser(pool *workerPool, fsys fs.FS, parsers ...parseFunc) (*parser, error) {
+	if len(parsers) < 1 {
+		return nil, errors.New("parsers slice cannot be empty")
 	}
+	return &parser{fsys: fsys, pool: pool, parsers: parsers}, nil
+}
 
-	var wg sync.WaitGroup
-	for _, entry := range dir {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			fullPath := filepath.Join(path, entry.Name())
-
-			if !entry.IsDir() {
-				err := p.parseFile(fullPath)
-				if err != nil {
-					log.Println(fullPath, err)
-				}
-				return
-			}
-
-			err = p.parseDir(fullPath)
-			if err != nil {
-				log.Println(fullPath, err)
-			}
-		}()
+// Parse walks all files and returns errors joined for every file that failed
+func (p *parser) Parse(ctx context.Context) error {
+	group := p.pool.Group(ctx)
+	err := fs.WalkDir(p.fsys, ".", func(path string, entry fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			group.Fail(path, errors.Wrap(err, "failed to read a directory"))
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		group.Go(path, func() error {
+			return p.parseFile(ctx, path)
+		})
+		return nil
+	})
+	if err != nil && ctx.Err() == nil {
+		group.Fail(".", err)
 	}
-	wg.Wait()
 
-	return nil
+	return group.Wait()
 }
 
-func (p *parser) parseFile(path string) error {
-	data, err := os.ReadFile(path)
+func (p *parser) parseFile(ctx context.Context, path string) error {
+	data, err := fs.ReadFile(p.fsys, path)
 	if err != nil {
 		return err
 	}
 
+	// every matching parser gets the file, an exclusive parser failing does not stop parsers of other assets
+	var errs []error
 	for _, parser := range p.parsers {
-		if parser.Match(path) {
-			log.Println("parsing", path)
-
-			err = parser.Parse(path, bytes.NewBuffer(data))
-			if parser.Exclusive() {
-				return errors.Wrap(err, "failed to parse a file")
-			}
-			if err != nil {
-				log.Println(path, errors.Wrap(err, "failed to parse a file"))
-			}
+		if !parser.Match(path) {
+			continue
+		}
+		logger := loggerFrom(ctx).With("path", path, "parser", parserName(parser))
+		logger.Debug("parsing")
+
+		err = parseWithContext(ctx, parser, path, bytes.NewBuffer(data))
+		if err == nil {
+			continue
+		}
+		if parser.Exclusive() || p.strict {
+			errs = append(errs, err)
+			continue
 		}
+		logger.Warn("failed to parse a file", "error", err)
+		report.Warn(path, err)
 	}
 
-	return nil
+	return errors.Wrap(joinErrors(errs...), "failed to parse a file")
 }
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matryer/is"
+	"golang.org/x/text/language"
+)
+
+func TestParserMapFS(t *testing.T) {
+	is := is.New(t)
+
+	fsys := fstest.MapFS{
+		"maps.yaml": &fstest.MapFile{Data: []byte(`
+maps:
+  karelia:
+    id: 1
+    localName: 17_karelia_ka/17_karelia_ka.sc2
+    availableModes: [0, 1]
+    supremacyPointsThreshold: 800
+`)},
+		"Strings/en.yaml": &fstest.MapFile{Data: []byte(`
+"#maps:karelia:17_karelia_ka/17_karelia_ka.sc2": Rockfield
+battleType/regular: Regular Battle
+`)},
+	}
+
+	maps := newMapParser()
+	tables, err := decodeStringTables(defaultStringTables)
+	is.NoErr(err)
+	gameModes := newStringTableParser(tables[0])
+
+	parser, err := newParser(newWorkerPool(2), fsys, maps.Maps(), gameModes)
+	is.NoErr(err)
+	is.NoErr(parser.Parse(context.Background()))
+
+	parser, err = newParser(newWorkerPool(2), fsys, maps.Strings())
+	is.NoErr(err)
+	is.NoErr(parser.Parse(context.Background()))
+
+	is.Equal(maps.maps["karelia"].LocalID, 1)
+	is.Equal(maps.localizedNames["karelia"][language.English], "Rockfield")
+	is.Equal(gameModes.values["game_mode_regular"][language.English], "Regular Battle")
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const defaultPluginTimeout = time.Minute
+
+// execPlugin is a config entry for a parser running an external command on every matching file
+type execPlugin struct {
+	Name string `yaml:"name"`
+	File string `yaml:"file"`
+	// Match is a regex matched against paths of parsed files
+	Match   string        `yaml:"match"`
+	Command []string      `yaml:"command"`
+	Timeout time.Duration `yaml:"timeout"`
+
+	matchRegex *regexp.Regexp
+}
+
+func decodeExecPlugins(data []byte) ([]execPlugin, error) {
+	plugins, err := decodeYAML[[]execPlugin](bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode plugins")
+	}
+
+	names := make(map[string]bool)
+	for i := range plugins {
+		plugin := &plugins[i]
+		if plugin.Name == "" || strings.Contains(plugin.Name, ".") {
+			return nil, errors.Errorf("plugin %d: invalid name %q", i, plugin.Name)
+		}
+		if names[plugin.Name] {
+			return nil, errors.Errorf("plugin %s: duplicate name", plugin.Name)
+		}
+		names[plugin.Name] = true
+		if plugin.File == "" {
+			return nil, errors.Errorf("plugin %s: file is required", plugin.Name)
+		}
+		if len(plugin.Command) == 0 {
+			return nil, errors.Errorf("plugin %s: command is required", plugin.Name)
+		}
+		if plugin.Timeout <= 0 {
+			plugin.Timeout = defaultPluginTimeout
+		}
+
+		plugin.matchRegex, err = regexp.Compile(plugin.Match)
+		if plugin.Match == "" || err != nil {
+			return nil, errors.Errorf("plugin %s: invalid match regex %q", plugin.Name, plugin.Match)
+		}
+	}
+	return plugins, nil
+}
+
+// registerExecPlugins reads plugins from a config file and registers every plugin as an asset
+func registerExecPlugins(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	plugins, err := decodeExecPlugins(data)
+	if err != nil {
+		return err
+	}
+
+	for _, plugin := range plugins {
+		if _, ok := assetRegistry[plugin.Name]; ok {
+			return errors.Errorf("plugin %s: asset is already registered", plugin.Name)
+		}
+	}
+	for _, plugin := range plugins {
+		registerAsset(plugin.Name, func() asset {
+			parser := newExecParser(plugin)
+			return asset{File: plugin.File, Parsers: map[string]parseFunc{plugin.Name: parser}, Exporter: parser}
+		})
+	}
+	return nil
+}
+
+// execParser streams every matching file to stdin of a command and merges json objects the command writes to stdout.
+// An asset is not exported if the command failed for any file, the run fails only when it is strict.
+type execParser struct {
+	plugin execPlugin
+
+	mx     sync.Mutex
+	failed int
+	values map[string]json.RawMessage
+}
+
+func newExecParser(plugin execPlugin) *execParser {
+	return &execParser{plugin: plugin, values: make(map[string]json.RawMessage)}
+}
+
+func (p *execParser) Exclusive() bool {
+	return false
+}
+
+func (p *execParser) Match(path string) bool {
+	return p.plugin.matchRegex.MatchString(path)
+}
+
+func (p *execParser) Parse(path string, r io.Reader) error {
+	return p.ParseContext(context.Background(), path, r)
+}
+
+func (p *execParser) ParseContext(ctx context.Context, path string, r io.Reader) error {
+	values, err := p.run(ctx, path, r)
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	if err != nil {
+		p.failed++
+		return err
+	}
+	for key, value := range values {
+		if _, ok := p.values[key]; ok {
+			p.failed++
+			return errors.Errorf("%s: duplicate key %s", p.plugin.Name, key)
+		}
+		p.values[key] = value
+	}
+	return nil
+}
+
+func (p *execParser) run(ctx context.Context, path string, r io.Reader) (map[string]json.RawMessage, error) {
+	ctx, cancel := context.WithTimeoutCause(ctx, p.plugin.Timeout, fmt.Errorf("%s: command did not finish in %s", p.plugin.Name, p.plugin.Timeout))
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.plugin.Command[0], p.plugin.Command[1:]...)
+	cmd.Env = append(os.Environ(), "PARSE_PATH="+path)
+	cmd.Stdin = r
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, context.Cause(ctx)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: command failed: %s", p.plugin.Name, lastLine(stderr.String()))
+	}
+
+	var values map[string]json.RawMessage
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return values, nil
+	}
+	err = json.Unmarshal(stdout.Bytes(), &values)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: command output is not a json object", p.plugin.Name)
+	}
+	return values, nil
+}
+
+// SkipExport leaves out the asset when the command failed for any file, in a strict run the asset fails instead
+func (p *execParser) SkipExport() error {
+	if p.failed > 0 {
+		return errors.Errorf("command failed for %d file(s)", p.failed)
+	}
+	return nil
+}
+
+func (p *execParser) Export(filePath string) (int, error) {
+	err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create path")
+	}
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create file")
+	}
+	defer f.Close()
+
+	e := json.NewEncoder(f)
+	e.SetIndent("", "  ")
+	return len(p.values), e.Encode(p.values)
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return lines[len(lines)-1]
+}
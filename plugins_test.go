@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matryer/is"
+)
+
+func TestExecPlugins(t *testing.T) {
+	is := is.New(t)
+
+	_, err := decodeExecPlugins([]byte("- {name: a, file: a.json, match: '('}"))
+	is.True(err != nil)
+	_, err = decodeExecPlugins([]byte("- {name: a, file: a.json, match: a}"))
+	is.True(err != nil) // command is required
+
+	plugins := filepath.Join(t.TempDir(), "plugins.yaml")
+	is.NoErr(os.WriteFile(plugins, []byte(`
+- name: test_lengths
+  file: lengths.json
+  match: \.txt$
+  command: [sh, -c, 'printf "{\"%s\": %s}" "$PARSE_PATH" "$(wc -c)"']
+- name: test_broken
+  file: broken.json
+  match: \.txt$
+  command: [sh, -c, 'case "$PARSE_PATH" in b*) echo "bad input" >&2; exit 1;; esac']
+- name: test_slow
+  file: slow.json
+  match: \.txt$
+  command: [sleep, "10"]
+  timeout: 10ms
+`), os.ModePerm))
+	is.NoErr(registerExecPlugins(plugins))
+	defer func() {
+		delete(assetRegistry, "test_lengths")
+		delete(assetRegistry, "test_broken")
+		delete(assetRegistry, "test_slow")
+	}()
+	is.True(registerExecPlugins(plugins) != nil) // already registered
+
+	config := testParseConfig(t)
+	fsys := fstest.MapFS{
+		"a.txt": &fstest.MapFile{Data: []byte("abc")},
+		"b.txt": &fstest.MapFile{Data: []byte("abcdef")},
+	}
+
+	// a failed command is a warning unless the run is strict
+	err = parseAssets(context.Background(), newWorkerPool(2), fsys, []string{"test_broken", "test_lengths", "test_slow"}, config)
+	is.NoErr(err)
+
+	data, err := os.ReadFile(filepath.Join(config.assetsPath, "lengths.json"))
+	is.NoErr(err)
+	var lengths map[string]int
+	is.NoErr(json.Unmarshal(data, &lengths))
+	is.Equal(lengths, map[string]int{"a.txt": 3, "b.txt": 6})
+
+	// a failed command fails only its own asset
+	_, err = os.Stat(filepath.Join(config.assetsPath, "broken.json"))
+	is.True(os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(config.assetsPath, "slow.json"))
+	is.True(os.IsNotExist(err))
+
+	config.strict = true
+	err = parseAssets(context.Background(), newWorkerPool(2), fsys, []string{"test_broken", "test_lengths"}, config)
+	is.True(err != nil)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// workerPool limits the number of files processed at the same time, a single pool is shared by all stages
+type workerPool struct {
+	sem chan struct{}
+}
+
+func newWorkerPool(size int) *workerPool {
+	if size < 1 {
+		size = runtime.NumCPU()
+	}
+	return &workerPool{sem: make(chan struct{}, size)}
+}
+
+// Group returns a new group of tasks running on this pool, tasks are not started once ctx is done
+func (p *workerPool) Group(ctx context.Context) *taskGroup {
+	return &taskGroup{pool: p, ctx: ctx}
+}
+
+// taskGroup runs tasks on a worker pool and collects their errors
+type taskGroup struct {
+	pool *workerPool
+	ctx  context.Context
+	wg   sync.WaitGroup
+
+	errorsMx sync.Mutex
+	errors   []error
+}
+
+// Go blocks until a worker is available and runs fn on it, an error is recorded for a given path
+func (g *taskGroup) Go(path string, fn func() error) {
+	select {
+	case g.pool.sem <- struct{}{}:
+	case <-g.ctx.Done():
+		return
+	}
+	if g.ctx.Err() != nil {
+		<-g.pool.sem
+		return
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer func() {
+			<-g.pool.sem
+			g.wg.Done()
+		}()
+
+		if err := fn(); err != nil {
+			g.Fail(path, err)
+		}
+	}()
+}
+
+// Fail records an error for a given path
+func (g *taskGroup) Fail(path string, err error) {
+	g.errorsMx.Lock()
+	defer g.errorsMx.Unlock()
+	g.errors = append(g.errors, &fileError{Path: path, Err: err})
+}
+
+// Wait waits for all tasks to finish and returns their errors joined and sorted by path.
+// Tasks skipped because the context is done are not reported, the caller is expected to check the context.
+func (g *taskGroup) Wait() error {
+	g.wg.Wait()
+
+	g.errorsMx.Lock()
+	defer g.errorsMx.Unlock()
+
+	slices.SortFunc(g.errors, func(a, b error) int {
+		return strings.Compare(a.(*fileError).Path, b.(*fileError).Path)
+	})
+	return errors.Join(g.errors...)
+}
+
+// joinErrors joins errors, nested joined errors are flattened so every error is printed on its own in a summary
+func joinErrors(errs ...error) error {
+	var flat []error
+	for _, err := range errs {
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			flat = append(flat, joined.Unwrap()...)
+			continue
+		}
+		if err != nil {
+			flat = append(flat, err)
+		}
+	}
+	return errors.Join(flat...)
+}
+
+type fileError struct {
+	Path string
+	Err  error
+}
+
+func (e *fileError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *fileError) Unwrap() error {
+	return e.Err
+}
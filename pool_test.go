@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestTaskGroup(t *testing.T) {
+	is := is.New(t)
+
+	pool := newWorkerPool(2)
+	group := pool.Group(context.Background())
+
+	var running, peak atomic.Int32
+	for i := range 16 {
+		group.Go(fmt.Sprintf("file-%02d", i), func() error {
+			current := running.Add(1)
+			defer running.Add(-1)
+			if current > peak.Load() {
+				peak.Store(current)
+			}
+			if i%4 == 0 {
+				return errors.New("failed")
+			}
+			return nil
+		})
+	}
+
+	err := group.Wait()
+	is.True(err != nil)
+	is.True(peak.Load() <= 2)
+
+	errs := err.(interface{ Unwrap() []error }).Unwrap()
+	is.Equal(len(errs), 4)
+	is.Equal(errs[0].Error(), "file-00: failed")
+	is.Equal(errs[3].Error(), "file-12: failed")
+}
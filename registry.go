@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+type assetExporter interface {
+	// Export writes the asset file and returns the number of exported entities
+	Export(filePath string) (int, error)
+}
+
+// exportSkipper is implemented by exporters which leave out an incomplete asset without failing a run that is not strict
+type exportSkipper interface {
+	// SkipExport returns a reason to not export the asset, or nil
+	SkipExport() error
+}
+
+// asset is a family of parsers producing a single exported file
+type asset struct {
+	// File is the name of the exported file
+	File string
+	// Parsers are keyed by name, a name is either the asset name or starts with the asset name and a dot
+	Parsers  map[string]parseFunc
+	Exporter assetExporter
+}
+
+// parseDependent is implemented by parsers which use results of other parsers,
+// they are run in a later phase than all parsers they depend on
+type parseDependent interface {
+	DependsOn() []string
+}
+
+// assetLinker is implemented by dependent parsers which read results of other assets,
+// Link is called with the exporter of every other asset a parser depends on before parsing starts
+type assetLinker interface {
+	Link(name string, exporter assetExporter) error
+}
+
+var assetRegistry = make(map[string]func() asset)
+
+// registerAsset registers a new asset family, init is called once per run when the asset is selected
+func registerAsset(name string, init func() asset) {
+	if _, ok := assetRegistry[name]; ok {
+		panic("asset " + name + " is already registered")
+	}
+	assetRegistry[name] = init
+}
+
+// selectAssets returns sorted names of registered assets, filtered by comma separated only and skip lists
+func selectAssets(only, skip string) ([]string, error) {
+	selected := make(map[string]bool)
+	if only == "" {
+		for name := range assetRegistry {
+			selected[name] = true
+		}
+	}
+	for _, name := range splitList(only) {
+		if _, ok := assetRegistry[name]; !ok {
+			return nil, errors.New("unknown asset " + name)
+		}
+		selected[name] = true
+	}
+	for _, name := range splitList(skip) {
+		if _, ok := assetRegistry[name]; !ok {
+			return nil, errors.New("unknown asset " + name)
+		}
+		delete(selected, name)
+	}
+
+	var names []string
+	for name := range selected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// registeredAsset is an initialized asset, it is marked as failed when one of its exclusive parsers returns an error
+type registeredAsset struct {
+	asset
+	name   string
+	failed atomic.Bool
+	// dependencies are other assets with parsers this asset depends on, set by parsePhases
+	dependencies map[string]*registeredAsset
+}
+
+func newRegisteredAsset(name string) (*registeredAsset, error) {
+	init, ok := assetRegistry[name]
+	if !ok {
+		return nil, errors.New("unknown asset " + name)
+	}
+
+	a := &registeredAsset{asset: init(), name: name}
+	for parserName := range a.Parsers {
+		if parserAsset(parserName) != name {
+			return nil, errors.Errorf("parser %s does not belong to asset %s", parserName, name)
+		}
+	}
+	return a, nil
+}
+
+// parserAsset returns the name of an asset a parser belongs to
+func parserAsset(parserName string) string {
+	name, _, _ := strings.Cut(parserName, ".")
+	return name
+}
+
+// parsePhases orders parsers of all assets into phases, every parser runs after all of its dependencies.
+// Assets providing a dependency are added to assets when missing, they are parsed but should not be exported.
+func parsePhases(assets map[string]*registeredAsset, strict bool) ([][]parseFunc, error) {
+	parsers := make(map[string]*assetParser)
+	var queue []string
+	for _, a := range assets {
+		for name := range a.Parsers {
+			queue = append(queue, name)
+		}
+	}
+
+	dependencies := make(map[string][]string)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if _, ok := parsers[name]; ok {
+			continue
+		}
+
+		a, ok := assets[parserAsset(name)]
+		if !ok {
+			var err error
+			a, err = newRegisteredAsset(parserAsset(name))
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to resolve parser "+name)
+			}
+			assets[a.name] = a
+		}
+		p, ok := a.Parsers[name]
+		if !ok {
+			return nil, errors.New("unknown parser " + name)
+		}
+		parsers[name] = &assetParser{parseFunc: p, asset: a, name: name, strict: strict}
+
+		if dependent, ok := p.(parseDependent); ok {
+			dependencies[name] = dependent.DependsOn()
+			queue = append(queue, dependent.DependsOn()...)
+		}
+	}
+
+	for name, dependsOn := range dependencies {
+		a := parsers[name].asset
+		for _, dependency := range dependsOn {
+			if dependencyAsset := parsers[dependency].asset; dependencyAsset != a {
+				if a.dependencies == nil {
+					a.dependencies = make(map[string]*registeredAsset)
+				}
+				a.dependencies[dependencyAsset.name] = dependencyAsset
+			}
+		}
+
+		linker, ok := parsers[name].parseFunc.(assetLinker)
+		if !ok {
+			continue
+		}
+		linked := make(map[string]bool)
+		for _, dependency := range dependsOn {
+			assetName := parserAsset(dependency)
+			if assetName == parserAsset(name) || linked[assetName] {
+				continue
+			}
+			linked[assetName] = true
+			err := linker.Link(assetName, assets[assetName].Exporter)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to link parser %s to %s", name, assetName)
+			}
+		}
+	}
+
+	var phases [][]parseFunc
+	done := make(map[string]bool)
+	for len(done) < len(parsers) {
+		var phase []string
+		for name := range parsers {
+			if done[name] {
+				continue
+			}
+			ready := true
+			for _, dependency := range dependencies[name] {
+				ready = ready && done[dependency]
+			}
+			if ready {
+				phase = append(phase, name)
+			}
+		}
+		if len(phase) == 0 {
+			return nil, errors.New("parsers have circular dependencies")
+		}
+
+		sort.Strings(phase)
+		var phaseParsers []parseFunc
+		for _, name := range phase {
+			done[name] = true
+			phaseParsers = append(phaseParsers, parsers[name])
+		}
+		phases = append(phases, phaseParsers)
+	}
+
+	return phases, nil
+}
+
+// failDependents marks every asset depending on a failed asset as failed, results of its parsers would be incomplete
+func failDependents(ctx context.Context, assets map[string]*registeredAsset) {
+	for changed := true; changed; {
+		changed = false
+		for _, a := range assets {
+			if a.failed.Load() {
+				continue
+			}
+			for _, dependency := range a.dependencies {
+				if dependency.failed.Load() {
+					loggerFrom(ctx).Warn("dependency failed", "asset", a.name, "dependency", dependency.name)
+					a.failed.Store(true)
+					changed = true
+					break
+				}
+			}
+		}
+	}
+}
+
+type assetParser struct {
+	parseFunc
+	asset *registeredAsset
+	name  string
+	// strict parsers fail their asset on any error, not only when they are exclusive
+	strict bool
+}
+
+func (p *assetParser) Name() string {
+	return p.name
+}
+
+func (p *assetParser) Parse(path string, r io.Reader) error {
+	return p.ParseContext(context.Background(), path, r)
+}
+
+func (p *assetParser) ParseContext(ctx context.Context, path string, r io.Reader) error {
+	report.Matched(p.name, path)
+
+	err := parseWithContext(ctx, p.parseFunc, path, r)
+	if err == nil {
+		return nil
+	}
+	if p.Exclusive() || p.strict {
+		p.asset.failed.Store(true)
+	}
+	return errors.Wrap(err, p.name)
+}
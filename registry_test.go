@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+)
+
+func TestSelectAssets(t *testing.T) {
+	is := is.New(t)
+
+	names, err := selectAssets("vehicles, maps", "")
+	is.NoErr(err)
+	is.Equal(names, []string{"maps", "vehicles"})
+
+	names, err = selectAssets("", "vehicles,maps")
+	is.NoErr(err)
+	is.Equal(len(names), len(assetRegistry)-2)
+
+	_, err = selectAssets("tanks", "")
+	is.True(err != nil)
+}
+
+func TestParseAssetsBrokenParser(t *testing.T) {
+	is := is.New(t)
+
+	config := testParseConfig(t)
+	fsys := fstest.MapFS{
+		"version.txt": &fstest.MapFile{Data: []byte("invalid")},
+		"maps.yaml":   &fstest.MapFile{Data: []byte("maps:\n  karelia:\n    id: 1\n")},
+	}
+
+	err := parseAssets(context.Background(), newWorkerPool(2), fsys, []string{"maps", "metadata"}, config)
+	is.True(err != nil)
+
+	_, err = os.Stat(filepath.Join(config.assetsPath, "maps.json"))
+	is.NoErr(err)
+	_, err = os.Stat(filepath.Join(config.assetsPath, "metadata.json"))
+	is.True(os.IsNotExist(err))
+}
+
+func TestParseAssetsFailedDependency(t *testing.T) {
+	is := is.New(t)
+
+	config := testParseConfig(t)
+	fsys := fstest.MapFS{
+		"XML/item_defs/vehicles/ussr/list.xml": &fstest.MapFile{Data: []byte("<root><T-34><id>0</id>")},
+		"XML/item_defs/vehicles/ussr/T-34.xml": &fstest.MapFile{Data: []byte("<root></root>")},
+		"maps.yaml":                            &fstest.MapFile{Data: []byte("maps:\n  karelia:\n    id: 1\n")},
+	}
+
+	// vehicles are parsed only as a dependency, a tech tree without them would be empty
+	err := parseAssets(context.Background(), newWorkerPool(2), fsys, []string{"maps", "tech_tree"}, config)
+	is.True(err != nil)
+
+	_, err = os.Stat(filepath.Join(config.assetsPath, "tech_tree.json"))
+	is.True(os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(config.assetsPath, "maps.json"))
+	is.NoErr(err)
+}
+
+type dependentParser struct {
+	versionParser
+	dependsOn []string
+}
+
+func (p *dependentParser) DependsOn() []string {
+	return p.dependsOn
+}
+
+func TestParsePhases(t *testing.T) {
+	is := is.New(t)
+
+	registerAsset("test_dependent", func() asset {
+		return asset{Parsers: map[string]parseFunc{
+			"test_dependent":       &dependentParser{dependsOn: []string{"vehicles.strings"}},
+			"test_dependent.cycle": &dependentParser{dependsOn: []string{"test_dependent.cycle"}},
+		}}
+	})
+	defer delete(assetRegistry, "test_dependent")
+
+	a, err := newRegisteredAsset("maps")
+	is.NoErr(err)
+	assets := map[string]*registeredAsset{"maps": a}
+
+	phases, err := parsePhases(assets, false)
+	is.NoErr(err)
+	is.Equal(len(phases), 2)
+	is.Equal(phases[0][0].(*assetParser).name, "maps")
+	is.Equal(phases[1][0].(*assetParser).name, "maps.strings")
+
+	a, err = newRegisteredAsset("test_dependent")
+	is.NoErr(err)
+	delete(a.Parsers, "test_dependent.cycle")
+	assets = map[string]*registeredAsset{"test_dependent": a}
+
+	// dependencies pull in parsers from assets which were not selected
+	phases, err = parsePhases(assets, false)
+	is.NoErr(err)
+	is.Equal(len(phases), 3)
+	is.Equal(phases[2][0].(*assetParser).name, "test_dependent")
+	is.True(assets["vehicles"] != nil)
+
+	a, err = newRegisteredAsset("test_dependent")
+	is.NoErr(err)
+	_, err = parsePhases(map[string]*registeredAsset{"test_dependent": a}, false)
+	is.True(err != nil)
+}
+
+// recordingParser records paths of parsed files and fails every file when fail is set
+type recordingParser struct {
+	mx        sync.Mutex
+	paths     []string
+	exclusive bool
+	fail      bool
+}
+
+func (p *recordingParser) Parse(path string, r io.Reader) error {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	p.paths = append(p.paths, path)
+	if p.fail {
+		return errors.New("broken file")
+	}
+	return nil
+}
+
+func (p *recordingParser) Exclusive() bool {
+	return p.exclusive
+}
+
+func (p *recordingParser) Match(path string) bool {
+	return path == "list.xml"
+}
+
+func TestParseSharedFile(t *testing.T) {
+	is := is.New(t)
+
+	parsers := map[string]*recordingParser{
+		"test_a": {exclusive: true},
+		"test_b": {exclusive: true, fail: true},
+		"test_c": {},
+	}
+	assets := make(map[string]*registeredAsset)
+	for name, p := range parsers {
+		assets[name] = &registeredAsset{name: name, asset: asset{Parsers: map[string]parseFunc{name: p}}}
+	}
+
+	phases, err := parsePhases(assets, false)
+	is.NoErr(err)
+	is.Equal(len(phases), 1)
+
+	parser, err := newParser(newWorkerPool(2), fstest.MapFS{"list.xml": &fstest.MapFile{}}, phases[0]...)
+	is.NoErr(err)
+	is.True(parser.Parse(context.Background()) != nil)
+
+	// parsers after an exclusive one still get the file, only the failed parser's asset is failed
+	for name, p := range parsers {
+		is.Equal(p.paths, []string{"list.xml"}) // every parser got the file
+		is.Equal(assets[name].failed.Load(), p.fail)
+	}
+}
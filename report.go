@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// report is written at the end of every run, including failed runs
+var report = newRunReport()
+
+type stageReport struct {
+	Name     string            `json:"name"`
+	Duration string            `json:"duration"`
+	Failed   bool              `json:"failed"`
+	Files    map[string]string `json:"failedFiles,omitempty"`
+	Errors   []string          `json:"errors,omitempty"`
+}
+
+type exportReport struct {
+	Asset    string `json:"asset"`
+	Entities int    `json:"entities"`
+	Error    string `json:"error,omitempty"`
+}
+
+type runReport struct {
+	mx *sync.Mutex
+
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Strict     bool      `json:"strict"`
+	Success    bool      `json:"success"`
+
+	Stages []stageReport `json:"stages"`
+	// Files are decrypted files grouped by status: decrypted, copied or unchanged
+	Files map[string][]string `json:"files"`
+	// Skipped are counts of files not selected by decrypt rules, keyed by the extension of a decoded file
+	Skipped map[string]int `json:"skipped"`
+	// Parsers are files matched by every parser
+	Parsers map[string][]string `json:"parsers"`
+	// Warnings are errors which do not fail the run unless it is strict
+	Warnings []string                `json:"warnings,omitempty"`
+	Exports  map[string]exportReport `json:"exports"`
+}
+
+func newRunReport() *runReport {
+	return &runReport{
+		mx:        &sync.Mutex{},
+		StartedAt: time.Now(),
+		Files:     make(map[string][]string),
+		Skipped:   make(map[string]int),
+		Parsers:   make(map[string][]string),
+		Exports:   make(map[string]exportReport),
+	}
+}
+
+func (r *runReport) File(status, path string) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.Files[status] = append(r.Files[status], path)
+}
+
+// Skip counts a file which was not selected by rules, a depot dump has too many of them to list
+func (r *runReport) Skip(path string) {
+	ext := filepath.Ext(strings.TrimSuffix(path, ".dvpl"))
+	if ext == "" {
+		ext = "none"
+	}
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.Skipped[ext]++
+}
+
+func (r *runReport) Matched(parser, path string) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.Parsers[parser] = append(r.Parsers[parser], path)
+}
+
+func (r *runReport) Warn(path string, err error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.Warnings = append(r.Warnings, path+": "+err.Error())
+}
+
+func (r *runReport) Exported(asset, file string, entities int, err error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	export := exportReport{Asset: asset, Entities: entities}
+	if err != nil {
+		export.Error = err.Error()
+	}
+	r.Exports[file] = export
+}
+
+// Stage records a finished stage, errors for specific files are listed separately
+func (r *runReport) Stage(name string, duration time.Duration, err error) {
+	stage := stageReport{Name: name, Duration: duration.String(), Failed: err != nil}
+	for _, err := range unwrapErrors(err) {
+		var fileErr *fileError
+		if errors.As(err, &fileErr) {
+			if stage.Files == nil {
+				stage.Files = make(map[string]string)
+			}
+			stage.Files[fileErr.Path] = fileErr.Err.Error()
+			continue
+		}
+		stage.Errors = append(stage.Errors, err.Error())
+	}
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.Stages = append(r.Stages, stage)
+}
+
+// Save writes the report to a file, a report is not written when the path is empty
+func (r *runReport) Save(path string, strict, success bool) error {
+	if path == "" {
+		return nil
+	}
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	r.Strict = strict
+	r.Success = success
+	r.FinishedAt = time.Now()
+	for _, files := range r.Files {
+		sort.Strings(files)
+	}
+	for _, files := range r.Parsers {
+		sort.Strings(files)
+	}
+	sort.Strings(r.Warnings)
+
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, os.ModePerm)
+}
+
+func unwrapErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
+
+// exitWithSummary prints every error joined into err, writes the run report and exits with a non-zero code
+func exitWithSummary(stage string, err error) {
+	logger := slog.With("stage", stage)
+
+	errs := unwrapErrors(err)
+	for _, err := range errs {
+		var fileErr *fileError
+		if errors.As(err, &fileErr) {
+			logger.Error("failed to process a file", "path", fileErr.Path, "error", fileErr.Err)
+			continue
+		}
+		logger.Error("stage error", "error", err)
+	}
+	logger.Error("stage failed", "errors", len(errs))
+
+	if err := report.Save(args.ReportPath, args.Strict, false); err != nil {
+		logger.Error("failed to write the run report", "error", err)
+	}
+	os.Exit(1)
+}
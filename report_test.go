@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestReportSkipped(t *testing.T) {
+	is := is.New(t)
+
+	r := newRunReport()
+	r.File("decrypted", "Data/Strings/en.yaml.dvpl")
+	r.Skip("Data/3d/Tanks/T-34.sc2.dvpl")
+	r.Skip("Data/3d/Tanks/KV-1.sc2.dvpl")
+	r.Skip("Data/Gfx/icon.dvpl")
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	is.NoErr(r.Save(path, true, true))
+
+	data, err := os.ReadFile(path)
+	is.NoErr(err)
+	var saved runReport
+	is.NoErr(json.Unmarshal(data, &saved))
+
+	// skipped files are only counted by extension
+	is.Equal(saved.Skipped, map[string]int{".sc2": 2, "none": 1})
+	is.Equal(saved.Files, map[string][]string{"decrypted": {"Data/Strings/en.yaml.dvpl"}})
+	is.True(saved.Strict)
+	is.True(saved.Success)
+}
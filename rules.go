@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// fileRule matches a file path the same way DepotDownloader matches filelist.txt lines,
+// a line is either a case-insensitive unanchored regular expression prefixed with regex: or an exact path.
+// Exact paths are compared case-insensitively and backslashes are treated as path separators.
+type fileRule struct {
+	regex *regexp.Regexp
+	path  string
+}
+
+func parseFileRule(line string) (fileRule, error) {
+	if expr, ok := strings.CutPrefix(line, "regex:"); ok {
+		regex, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			return fileRule{}, errors.Wrap(err, "invalid rule "+line)
+		}
+		return fileRule{regex: regex}, nil
+	}
+	return fileRule{path: strings.ReplaceAll(line, "\\", "/")}, nil
+}
+
+func (r fileRule) Match(filePath string) bool {
+	if r.regex != nil {
+		return r.regex.MatchString(filePath)
+	}
+	return strings.EqualFold(r.path, filePath)
+}
+
+// fileRules selects files by include and exclude rules, a file is selected when
+// it matches any include rule (or there are none) and does not match any exclude rule
+type fileRules struct {
+	include []fileRule
+	exclude []fileRule
+}
+
+// defaultDecryptRules are used when no rules are configured, they select passthrough files of the same types
+var defaultDecryptRules = []string{`regex:\.(yaml|xml|txt)(\.dvpl)?$`}
+
+func newFileRules(include, exclude []string) (fileRules, error) {
+	var rules fileRules
+	for _, line := range include {
+		rule, err := parseFileRule(line)
+		if err != nil {
+			return rules, err
+		}
+		rules.include = append(rules.include, rule)
+	}
+	for _, line := range exclude {
+		rule, err := parseFileRule(line)
+		if err != nil {
+			return rules, err
+		}
+		rules.exclude = append(rules.exclude, rule)
+	}
+	return rules, nil
+}
+
+// readRulesFile reads rules from a file, one rule per line. Lines starting with ! are exclude rules.
+func readRulesFile(filePath string) (include []string, exclude []string, err error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if rule, ok := strings.CutPrefix(line, "!"); ok {
+			exclude = append(exclude, rule)
+			continue
+		}
+		include = append(include, line)
+	}
+	return include, exclude, scanner.Err()
+}
+
+func (r fileRules) Included(filePath string) bool {
+	if len(r.include) == 0 {
+		return true
+	}
+	for _, rule := range r.include {
+		if rule.Match(filePath) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r fileRules) Excluded(filePath string) bool {
+	for _, rule := range r.exclude {
+		if rule.Match(filePath) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r fileRules) Match(filePath string) bool {
+	return r.Included(filePath) && !r.Excluded(filePath)
+}
+
+// Selected reports if a file is decrypted, files which are not dvpl encoded are selected only in passthrough mode
+func (r fileRules) Selected(filePath string, passthrough bool) bool {
+	if !strings.HasSuffix(filePath, ".dvpl") && !passthrough {
+		return false
+	}
+	return r.Match(filePath)
+}
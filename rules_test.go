@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestFileRules(t *testing.T) {
+	is := is.New(t)
+
+	rulesPath := filepath.Join(t.TempDir(), "rules.txt")
+	is.NoErr(os.WriteFile(rulesPath, []byte(`
+regex:Data/XML/item_defs/vehicles/.*list.xml.dvpl
+regex:^Data/Strings/[^/]+\.yaml\.dvpl$
+Data\version.txt.dvpl
+!Data/XML/item_defs/vehicles/provisions/list.xml.dvpl
+`), os.ModePerm))
+
+	include, exclude, err := readRulesFile(rulesPath)
+	is.NoErr(err)
+	rules, err := newFileRules(include, exclude)
+	is.NoErr(err)
+
+	is.True(rules.Match("Data/XML/item_defs/vehicles/ussr/list.xml.dvpl"))
+	is.True(rules.Match("Data/xml/item_defs/vehicles/ussr/LIST.xml.dvpl"))     // regex rules ignore case
+	is.True(rules.Match("Data/XML/item_defs/vehicles/ussr/list.xml.dvpl.bak")) // and are not anchored
+	is.True(rules.Match("Data/Strings/en.yaml.dvpl"))
+	is.True(rules.Match("Data/version.txt.dvpl"))
+	is.True(rules.Match("data/Version.txt.dvpl")) // exact paths ignore case
+	is.True(!rules.Match("Data/version.txt"))
+	is.True(!rules.Match("Data/XML/item_defs/vehicles/provisions/list.xml.dvpl"))
+	is.True(!rules.Match("Data/Strings/nested/en.yaml.dvpl"))
+	is.True(!rules.Match("Data/maps.yaml.dvpl"))
+
+	defaults, err := newFileRules(defaultDecryptRules, nil)
+	is.NoErr(err)
+	is.True(defaults.Match("Data/maps.yaml.dvpl"))
+	is.True(defaults.Match("Data/maps.yaml")) // passthrough files
+	is.True(!defaults.Match("Data/3d/tank.sc2.dvpl"))
+
+	_, err = newFileRules([]string{"regex:("}, nil)
+	is.True(err != nil)
+}
+
+func TestPassthroughRules(t *testing.T) {
+	is := is.New(t)
+
+	dump := t.TempDir()
+	out := t.TempDir()
+	is.NoErr(os.MkdirAll(filepath.Join(dump, "Data"), os.ModePerm))
+	is.NoErr(os.WriteFile(filepath.Join(dump, "Data", "maps.yaml"), []byte("maps: {}"), os.ModePerm))
+	is.NoErr(os.WriteFile(filepath.Join(dump, "Data", "tank.sc2"), []byte("binary"), os.ModePerm))
+
+	rules, err := newFileRules(defaultDecryptRules, nil)
+	is.NoErr(err)
+	d := &decrypter{pool: newWorkerPool(2), rules: rules, rulesRoot: dump, passthrough: true}
+	is.NoErr(d.decryptDir(context.Background(), filepath.Join(dump, "Data"), out))
+
+	// passthrough files are copied only when they match include rules
+	is.Equal(d.stats.Copied.Load(), int64(1))
+	_, err = os.Stat(filepath.Join(out, "maps.yaml"))
+	is.NoErr(err)
+	_, err = os.Stat(filepath.Join(out, "tank.sc2"))
+	is.True(os.IsNotExist(err))
+}
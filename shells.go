@@ -0,0 +1,391 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cufee/aftermath-assets/globalid"
+	"github.com/cufee/aftermath-assets/types"
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+)
+
+func init() {
+	registerAsset("shells", func() asset {
+		shells := newShellsParser()
+		return asset{File: "shells.json", Parsers: map[string]parseFunc{"shells": shells.Components(), "shells.guns": shells.Guns(), "shells.vehicles": shells.Vehicles(), "shells.strings": shells.Strings()}, Exporter: shells}
+	})
+}
+
+var shellsRegex = regexp.MustCompile(`(^|/)XML/item_defs/vehicles/([^/]+)/components/shells\.xml$`)
+
+var shellTypes = map[string]string{
+	"ARMOR_PIERCING":    "AP",
+	"ARMOR_PIERCING_CR": "APCR",
+	"HIGH_EXPLOSIVE":    "HE",
+	"HOLLOW_CHARGE":     "HEAT",
+}
+
+// shellPenetrationDistances are distances of values listed in piercingPower of a shot
+var shellPenetrationDistances = []int{100, 500}
+
+type shellsParser struct {
+	modules  *modulesParser
+	vehicles *vehicleDetailsParser
+
+	shellNames map[string]map[language.Tag]string
+	shells     map[string]types.Shell
+	// shellIDs are keyed by nation and shell name, <nation>/<name>
+	shellIDs map[string]string
+	lock     *sync.Mutex
+}
+
+func newShellsParser() *shellsParser {
+	return &shellsParser{
+		lock:       &sync.Mutex{},
+		shells:     make(map[string]types.Shell),
+		shellIDs:   make(map[string]string),
+		shellNames: make(map[string]map[language.Tag]string),
+	}
+}
+
+func (p *shellsParser) Components() *shellComponentsParser {
+	return &shellComponentsParser{p}
+}
+func (p *shellsParser) Guns() *shellGunsParser {
+	return &shellGunsParser{p}
+}
+func (p *shellsParser) Vehicles() *shellVehiclesParser {
+	return &shellVehiclesParser{p}
+}
+func (p *shellsParser) Strings() *shellStringsParser {
+	return &shellStringsParser{p}
+}
+
+// Link is used by parsers of shells depending on other assets
+func (p *shellsParser) Link(name string, exporter assetExporter) error {
+	switch e := exporter.(type) {
+	case *modulesParser:
+		p.modules = e
+	case *vehicleDetailsParser:
+		p.vehicles = e
+	default:
+		return errors.New("unexpected exporter for " + name)
+	}
+	return nil
+}
+
+// Export links shells to vehicles through shared guns vehicles have in their turrets and guns defined by vehicles
+func (p *shellsParser) Export(filePath string) (int, error) {
+	err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create path")
+	}
+
+	// a vehicle overriding shots of a shared gun only fires shells listed in its own shots
+	overrides := make(map[string]bool)
+	for _, shell := range p.shells {
+		for _, gun := range shell.Guns {
+			if gun.ID != "" && gun.Vehicle != "" {
+				overrides[gun.ID+"/"+gun.Vehicle] = true
+			}
+		}
+	}
+
+	gunVehicles := make(map[string][]string)
+	for id, vehicle := range p.vehicles.details {
+		for _, turret := range vehicle.Turrets {
+			for _, gun := range turret.Guns {
+				gunID, ok := p.modules.moduleID(vehicle.Nation, "gun", gun.Key)
+				if ok && !overrides[gunID+"/"+id] && !slices.Contains(gunVehicles[gunID], id) {
+					gunVehicles[gunID] = append(gunVehicles[gunID], id)
+				}
+			}
+		}
+	}
+
+	shells := make(map[string]types.Shell)
+	for id, shell := range p.shells {
+		shell.Vehicles = []string{}
+		if shell.Guns == nil {
+			shell.Guns = []types.ShellGun{}
+		}
+		for _, gun := range shell.Guns {
+			vehicles := gunVehicles[gun.ID]
+			if gun.Vehicle != "" {
+				vehicles = []string{gun.Vehicle}
+			}
+			for _, vehicle := range vehicles {
+				if !slices.Contains(shell.Vehicles, vehicle) {
+					shell.Vehicles = append(shell.Vehicles, vehicle)
+				}
+			}
+		}
+		slices.Sort(shell.Vehicles)
+		slices.SortFunc(shell.Guns, func(a, b types.ShellGun) int {
+			return cmp.Or(strings.Compare(a.ID, b.ID), strings.Compare(a.Name, b.Name), strings.Compare(a.Vehicle, b.Vehicle))
+		})
+
+		shell.LocalizedNames = reduceLocalizedNames(localizedOrEmpty(p.shellNames[id]))
+		shells[id] = shell
+	}
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create file")
+	}
+	defer f.Close()
+
+	e := json.NewEncoder(f)
+	e.SetIndent("", "  ")
+	return len(shells), e.Encode(shells)
+}
+
+// shellComponentsParser parses shell definitions of a nation
+type shellComponentsParser struct {
+	*shellsParser
+}
+
+func (p *shellComponentsParser) Exclusive() bool {
+	return false
+}
+func (p *shellComponentsParser) Match(path string) bool {
+	match := shellsRegex.FindStringSubmatch(path)
+	if match == nil {
+		return false
+	}
+	_, ok := globalid.NationID(match[2])
+	return ok
+}
+func (p *shellComponentsParser) Parse(filePath string, r io.Reader) error {
+	nation := shellsRegex.FindStringSubmatch(filePath)[2]
+
+	root, err := decodeXMLTree(r)
+	if err != nil {
+		return err
+	}
+
+	var shells []types.Shell
+	for _, node := range root.Children {
+		// shells are listed next to other elements, such as icons
+		if node.Value("id") == "" || node.Value("kind") == "" {
+			continue
+		}
+
+		id, err := globalid.EncodeItem(globalid.KindShell, nation, node.Int("id"))
+		if err != nil {
+			return errors.Wrap(err, "invalid id for shell "+node.Name)
+		}
+
+		kind := node.Value("kind")
+		if shellType, ok := shellTypes[kind]; ok {
+			kind = shellType
+		}
+		shells = append(shells, types.Shell{
+			ID:     fmt.Sprint(id),
+			Key:    node.Value("userString"),
+			Name:   node.Name,
+			Type:   kind,
+			Nation: nation,
+
+			Caliber: node.Float("caliber"),
+			Damage:  node.Int("damage/armor"),
+			Price:   itemPrice(node),
+		})
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, shell := range shells {
+		p.shells[shell.ID] = shell
+		p.shellIDs[nation+"/"+shell.Name] = shell.ID
+	}
+	return nil
+}
+
+// shellGunsParser parses shots of shared guns, a shot sets speed and penetration of a shell fired from a gun
+type shellGunsParser struct {
+	*shellsParser
+}
+
+func (p *shellGunsParser) DependsOn() []string {
+	return []string{"shells", "modules"}
+}
+func (p *shellGunsParser) Exclusive() bool {
+	return false
+}
+func (p *shellGunsParser) Match(path string) bool {
+	match := componentsRegex.FindStringSubmatch(path)
+	if match == nil || match[3] != "guns" {
+		return false
+	}
+	_, ok := globalid.NationID(match[2])
+	return ok
+}
+func (p *shellGunsParser) Parse(filePath string, r io.Reader) error {
+	nation := componentsRegex.FindStringSubmatch(filePath)[2]
+
+	root, err := decodeXMLTree(r)
+	if err != nil {
+		return err
+	}
+	guns := root.Child("shared")
+	if guns == nil {
+		return errors.New("missing shared module definitions")
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var errs []error
+	for _, gun := range guns.Children {
+		gunID, ok := p.modules.moduleID(nation, "gun", gun.Name)
+		if !ok {
+			continue
+		}
+		errs = append(errs, p.addShots(nation, gun, types.ShellGun{ID: gunID, Name: gun.Name})...)
+	}
+	return joinErrors(errs...)
+}
+
+// addShots adds a gun to every shell listed in its shots, values missing in a shot are taken from the shared gun.
+// Shells which are not defined are skipped and returned as errors.
+func (p *shellsParser) addShots(nation string, gun *xmlNode, shellGun types.ShellGun) []error {
+	shots := gun.Child("shots")
+	if shots == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, shot := range shots.Children {
+		id, ok := p.shellIDs[nation+"/"+shot.Name]
+		if !ok {
+			errs = append(errs, errors.Errorf("gun %s fires an unknown shell %s", gun.Name, shot.Name))
+			continue
+		}
+		shell := p.shells[id]
+
+		var shared types.ShellGun
+		if shellGun.Vehicle != "" && shellGun.ID != "" {
+			for _, g := range shell.Guns {
+				if g.ID == shellGun.ID && g.Vehicle == "" {
+					shared = g
+				}
+			}
+		}
+
+		gunShot := shellGun
+		gunShot.Speed = shared.Speed
+		if shot.Value("speed") != "" {
+			gunShot.Speed = shot.Float("speed")
+		}
+		gunShot.Penetration = shared.Penetration
+		if shot.Value("piercingPower") != "" {
+			gunShot.Penetration = shotPenetration(shot)
+		}
+
+		shell.Guns = append(shell.Guns, gunShot)
+		p.shells[id] = shell
+	}
+	return errs
+}
+
+func shotPenetration(shot *xmlNode) []types.ShellPenetration {
+	var penetration []types.ShellPenetration
+	for i, value := range strings.Fields(shot.Value("piercingPower")) {
+		if i >= len(shellPenetrationDistances) {
+			break
+		}
+		v, _ := strconv.ParseFloat(value, 64)
+		penetration = append(penetration, types.ShellPenetration{Distance: shellPenetrationDistances[i], Value: v})
+	}
+	return penetration
+}
+
+// shellVehiclesParser parses shots of guns in vehicle definitions, a vehicle can define its own gun
+// or override shots of a shared gun, such guns are added with the id of the vehicle
+type shellVehiclesParser struct {
+	*shellsParser
+}
+
+func (p *shellVehiclesParser) DependsOn() []string {
+	return []string{"shells.guns", "vehicle_details"}
+}
+func (p *shellVehiclesParser) Exclusive() bool {
+	return false
+}
+func (p *shellVehiclesParser) Match(path string) bool {
+	_, _, ok := matchVehicleDefinition(path)
+	return ok
+}
+func (p *shellVehiclesParser) Parse(filePath string, r io.Reader) error {
+	nation, name, _ := matchVehicleDefinition(filePath)
+	vehicleID, ok := p.vehicles.vehicles.vehicleID(nation, name)
+	if !ok {
+		return nil
+	}
+
+	root, err := decodeXMLTree(r)
+	if err != nil {
+		return err
+	}
+	turrets := root.Child("turrets0")
+	if turrets == nil {
+		return nil
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var errs []error
+	for _, turret := range turrets.Children {
+		guns := turret.Child("guns")
+		if guns == nil {
+			continue
+		}
+		for _, gun := range guns.Children {
+			gunID, _ := p.modules.moduleID(nation, "gun", gun.Name)
+			errs = append(errs, p.addShots(nation, gun, types.ShellGun{ID: gunID, Name: gun.Name, Vehicle: vehicleID})...)
+		}
+	}
+	return joinErrors(errs...)
+}
+
+// shellStringsParser adds localized shell names
+type shellStringsParser struct {
+	*shellsParser
+}
+
+func (p *shellStringsParser) DependsOn() []string {
+	return []string{"shells"}
+}
+func (p *shellStringsParser) Exclusive() bool {
+	return false
+}
+func (p *shellStringsParser) Match(path string) bool {
+	return jsonStringsRegex.MatchString(path)
+}
+func (p *shellStringsParser) Parse(filePath string, r io.Reader) error {
+	locale, data, err := decodeJSONStrings(filePath, r)
+	if err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for id, shell := range p.shells {
+		addLocalized(p.shellNames, id, locale, data, shell.Key)
+	}
+
+	return nil
+}
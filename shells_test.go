@@ -0,0 +1,110 @@
+package main
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/cufee/aftermath-assets/types"
+	"github.com/matryer/is"
+	"golang.org/x/text/language"
+)
+
+const testShells = `<root>
+	<icons>
+		<ARMOR_PIERCING>shell_ap.png</ARMOR_PIERCING>
+	</icons>
+	<_76mm_UBR-354MA>
+		<id>1</id>
+		<userString>#ussr_vehicles:_76mm_UBR-354MA</userString>
+		<kind>ARMOR_PIERCING</kind>
+		<caliber>76.2</caliber>
+		<price>56</price>
+		<damage><armor>110</armor><devices>80</devices></damage>
+	</_76mm_UBR-354MA>
+	<_76mm_OF-350>
+		<id>2</id>
+		<kind>HIGH_EXPLOSIVE</kind>
+		<damage><armor>180</armor></damage>
+	</_76mm_OF-350>
+	<_76mm_BR-350SP>
+		<id>3</id>
+		<kind>ARMOR_PIERCING_CR</kind>
+		<price>4<gold/></price>
+		<damage><armor>110</armor></damage>
+	</_76mm_BR-350SP>
+</root>`
+
+const testGunShots = `<root>
+	<ids>
+		<_76mm_L-11>1</_76mm_L-11>
+	</ids>
+	<shared>
+		<_76mm_L-11>
+			<shots>
+				<_76mm_BR-350A><speed>600</speed></_76mm_BR-350A>
+				<_76mm_UBR-354MA>
+					<speed>612</speed>
+					<piercingPower>86 67</piercingPower>
+				</_76mm_UBR-354MA>
+				<_76mm_BR-350SP><speed>760</speed><piercingPower>120 100</piercingPower></_76mm_BR-350SP>
+			</shots>
+		</_76mm_L-11>
+	</shared>
+</root>`
+
+const testShellsVehicle = `<root>
+	<chassis><KV-1_chassis>shared</KV-1_chassis></chassis>
+	<turrets0>
+		<KV-1_turret>
+			<guns>
+				<_76mm_L-11>shared
+					<shots><_76mm_UBR-354MA><piercingPower>90 70</piercingPower></_76mm_UBR-354MA></shots>
+				</_76mm_L-11>
+				<_76mm_F-32>
+					<shots><_76mm_OF-350><speed>600</speed><piercingPower>38 38</piercingPower></_76mm_OF-350></shots>
+				</_76mm_F-32>
+			</guns>
+		</KV-1_turret>
+	</turrets0>
+	<engines><V-2>shared</V-2></engines>
+</root>`
+
+func TestShells(t *testing.T) {
+	is := is.New(t)
+
+	fsys := fstest.MapFS{
+		"XML/item_defs/vehicles/ussr/list.xml":              &fstest.MapFile{Data: []byte("<root><T-34><id>0</id></T-34><KV-1><id>1</id></KV-1></root>")},
+		"XML/item_defs/vehicles/ussr/T-34.xml":              &fstest.MapFile{Data: []byte(testVehicleDefinition)},
+		"XML/item_defs/vehicles/ussr/KV-1.xml":              &fstest.MapFile{Data: []byte(testShellsVehicle)},
+		"XML/item_defs/vehicles/ussr/components/guns.xml":   &fstest.MapFile{Data: []byte(testGunShots)},
+		"XML/item_defs/vehicles/ussr/components/shells.xml": &fstest.MapFile{Data: []byte(testShells)},
+		"Strings/en.json": &fstest.MapFile{Data: []byte(`{"#ussr_vehicles:_76mm_UBR-354MA": "UBR-354MA"}`)},
+	}
+
+	shells := readTestAsset[map[string]types.Shell](t, parseTestAssets(t, fsys, "shells"), "shells.json")
+	is.Equal(len(shells), 3)
+
+	ap := shells["266"]
+	is.Equal(ap.Type, "AP")
+	is.Equal(ap.Damage, 110)
+	is.Equal(ap.Price, types.Price{Amount: 56, Currency: "credits"})
+	is.Equal(ap.LocalizedNames[language.English], "UBR-354MA")
+	// an unknown shell listed before does not stop other shots of a gun, a vehicle can override some values of a shot
+	is.Equal(ap.Guns, []types.ShellGun{
+		{ID: "260", Name: "_76mm_L-11", Speed: 612, Penetration: []types.ShellPenetration{{Distance: 100, Value: 86}, {Distance: 500, Value: 67}}},
+		{ID: "260", Name: "_76mm_L-11", Vehicle: "257", Speed: 612, Penetration: []types.ShellPenetration{{Distance: 100, Value: 90}, {Distance: 500, Value: 70}}},
+	})
+	is.Equal(ap.Vehicles, []string{"1", "257"})
+
+	// guns defined in a vehicle definition are linked to their vehicle
+	he := shells["522"]
+	is.Equal(he.Type, "HE")
+	is.True(he.LocalizedNames != nil) // exported as an empty object
+	is.Equal(he.Guns, []types.ShellGun{{Name: "_76mm_F-32", Vehicle: "257", Speed: 600, Penetration: []types.ShellPenetration{{Distance: 100, Value: 38}, {Distance: 500, Value: 38}}}})
+	is.Equal(he.Vehicles, []string{"257"})
+
+	// a vehicle overriding shots of a shared gun does not fire shells missing from its own shots
+	apcr := shells["778"]
+	is.Equal(apcr.Price, types.Price{Amount: 4, Currency: "gold"})
+	is.Equal(apcr.Vehicles, []string{"1"})
+}
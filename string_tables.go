@@ -0,0 +1,233 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+)
+
+//go:embed string_tables.yaml
+var defaultStringTables []byte
+
+// stringTableAssets are names of registered string table assets, they are replaced when a custom config is used
+var stringTableAssets []string
+
+func init() {
+	tables, err := decodeStringTables(defaultStringTables)
+	if err != nil {
+		panic(err)
+	}
+	err = registerStringTables(tables)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// stringTable is a config entry for a localized dictionary exported from the Strings files
+type stringTable struct {
+	Name      string `yaml:"name"`
+	File      string `yaml:"file"`
+	Prefix    string `yaml:"prefix"`
+	Regex     string `yaml:"regex"`
+	Key       string `yaml:"key"`
+	Lowercase bool   `yaml:"lowercase"`
+
+	keyRegex *regexp.Regexp
+}
+
+func decodeStringTables(data []byte) ([]stringTable, error) {
+	tables, err := decodeYAML[[]stringTable](strings.NewReader(string(data)))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode string tables")
+	}
+
+	names := make(map[string]bool)
+	for i := range tables {
+		table := &tables[i]
+		if table.Name == "" || strings.Contains(table.Name, ".") {
+			return nil, errors.Errorf("string table %d: invalid name %q", i, table.Name)
+		}
+		if names[table.Name] {
+			return nil, errors.Errorf("string table %s: duplicate name", table.Name)
+		}
+		names[table.Name] = true
+		if table.File == "" {
+			return nil, errors.Errorf("string table %s: file is required", table.Name)
+		}
+
+		switch {
+		case table.Prefix != "" && table.Regex != "":
+			return nil, errors.Errorf("string table %s: prefix and regex cannot be used together", table.Name)
+		case table.Prefix != "":
+			table.keyRegex = regexp.MustCompile("^" + regexp.QuoteMeta(table.Prefix) + "(.*)$")
+			if table.Key == "" {
+				table.Key = "${1}"
+			}
+		case table.Regex != "":
+			table.keyRegex, err = regexp.Compile("^(?:" + table.Regex + ")$")
+			if err != nil {
+				return nil, errors.Wrapf(err, "string table %s: invalid regex", table.Name)
+			}
+			if table.Key == "" {
+				table.Key = "${0}"
+			}
+		default:
+			return nil, errors.Errorf("string table %s: prefix or regex is required", table.Name)
+		}
+	}
+	return tables, nil
+}
+
+// registerStringTables registers every table as an asset
+func registerStringTables(tables []stringTable) error {
+	for _, table := range tables {
+		if _, ok := assetRegistry[table.Name]; ok {
+			return errors.Errorf("string table %s: asset is already registered", table.Name)
+		}
+	}
+
+	for _, table := range tables {
+		registerAsset(table.Name, func() asset {
+			parser := newStringTableParser(table)
+			return asset{File: table.File, Parsers: map[string]parseFunc{table.Name: parser}, Exporter: parser}
+		})
+		stringTableAssets = append(stringTableAssets, table.Name)
+	}
+	return nil
+}
+
+// useStringTables replaces string tables from the default config with tables from a config file
+func useStringTables(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	tables, err := decodeStringTables(data)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range stringTableAssets {
+		delete(assetRegistry, name)
+	}
+	stringTableAssets = nil
+	return registerStringTables(tables)
+}
+
+type stringTableParser struct {
+	table stringTable
+
+	valuesMx *sync.Mutex
+	values   map[string]map[language.Tag]string
+}
+
+func newStringTableParser(table stringTable) *stringTableParser {
+	return &stringTableParser{
+		table:    table,
+		valuesMx: &sync.Mutex{},
+		values:   make(map[string]map[language.Tag]string),
+	}
+}
+
+func (p *stringTableParser) Exclusive() bool {
+	return false
+}
+
+func (p *stringTableParser) Match(path string) bool {
+	return stringsRegex.MatchString(path)
+}
+
+func (p *stringTableParser) Export(filePath string) (int, error) {
+	err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create path")
+	}
+
+	table := make(map[string]map[string]string)
+	for key, localized := range p.values {
+		names := make(map[string]string)
+		for locale, value := range localized {
+			names[locale.String()] = value
+		}
+		table[key] = names
+	}
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create file")
+	}
+	defer f.Close()
+
+	e := json.NewEncoder(f)
+	e.SetIndent("", "  ")
+	return len(table), e.Encode(table)
+}
+
+func (p *stringTableParser) Parse(path string, r io.Reader) error {
+	lang := strings.Split(filepath.Base(path), ".")[0]
+	locale, err := language.Parse(lang)
+	if err != nil {
+		return errors.Wrap(err, "failed to get locale from a filename")
+	}
+
+	data, err := decodeYAML[map[string]string](r)
+	if err != nil {
+		return err
+	}
+
+	// keys are rewritten in a sorted order, so the same key wins when two keys are exported under one name
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	p.valuesMx.Lock()
+	defer p.valuesMx.Unlock()
+
+	var errs []error
+	sources := make(map[string]string)
+	for _, key := range keys {
+		name, ok := p.table.rewrite(key)
+		if !ok {
+			continue
+		}
+		if source, ok := sources[name]; ok {
+			errs = append(errs, errors.Errorf("%s and %s are both exported as %s, %s is used", source, key, name, source))
+			continue
+		}
+		sources[name] = key
+
+		localized, ok := p.values[name]
+		if !ok {
+			localized = make(map[language.Tag]string)
+		}
+		localized[locale] = data[key]
+		p.values[name] = localized
+	}
+
+	return joinErrors(errs...)
+}
+
+// rewrite returns an exported key for a key from the Strings files, ok is false when the key does not belong to the table
+func (t stringTable) rewrite(key string) (string, bool) {
+	match := t.keyRegex.FindStringSubmatchIndex(key)
+	if match == nil {
+		return "", false
+	}
+
+	name := string(t.keyRegex.ExpandString(nil, t.Key, key, match))
+	if t.Lowercase {
+		name = strings.ToLower(name)
+	}
+	return name, true
+}
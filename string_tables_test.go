@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"golang.org/x/text/language"
+)
+
+func TestStringTables(t *testing.T) {
+	is := is.New(t)
+
+	tables, err := decodeStringTables([]byte(`
+- name: game_modes
+  file: game_modes.json
+  regex: battleType/([^/]+)
+  key: game_mode_${1}
+  lowercase: true
+- name: nations
+  file: nations.json
+  prefix: "#menu:nations/"
+`))
+	is.NoErr(err)
+	is.Equal(len(tables), 2)
+
+	key, ok := tables[0].rewrite("battleType/Regular")
+	is.True(ok)
+	is.Equal(key, "game_mode_regular")
+	_, ok = tables[0].rewrite("battleType/regular/description")
+	is.True(!ok)
+	_, ok = tables[0].rewrite("menu/battleType/regular")
+	is.True(!ok)
+
+	key, ok = tables[1].rewrite("#menu:nations/usa")
+	is.True(ok)
+	is.Equal(key, "usa")
+
+	for _, config := range []string{
+		"- {file: a.json, prefix: a}",
+		"- {name: a.b, file: a.json, prefix: a}",
+		"- {name: a, prefix: a}",
+		"- {name: a, file: a.json}",
+		"- {name: a, file: a.json, prefix: a, regex: a}",
+		"- {name: a, file: a.json, regex: '('}",
+		"- {name: a, file: a.json, prefix: a}\n- {name: a, file: b.json, prefix: b}",
+	} {
+		_, err := decodeStringTables([]byte(config))
+		is.True(err != nil) // invalid config
+	}
+}
+
+func TestStringTableCollisions(t *testing.T) {
+	is := is.New(t)
+
+	tables, err := decodeStringTables(defaultStringTables)
+	is.NoErr(err)
+
+	// keys which differ only in case are exported under one lowercase key, the first key in a sorted order is used
+	for range 10 {
+		parser := newStringTableParser(tables[0])
+		err := parser.Parse("Strings/en.yaml", strings.NewReader("battleType/foo: Lower\nbattleType/Foo: Upper\n"))
+		is.True(err != nil)
+		is.True(strings.Contains(err.Error(), "game_mode_foo"))
+		is.Equal(parser.values["game_mode_foo"][language.English], "Upper")
+	}
+}
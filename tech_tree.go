@@ -0,0 +1,155 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/cufee/aftermath-assets/types"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registerAsset("tech_tree", func() asset {
+		tree := newTechTreeParser()
+		return asset{File: "tech_tree.json", Parsers: map[string]parseFunc{"tech_tree": tree}, Exporter: tree}
+	})
+}
+
+// techTreeParser builds a research graph of every nation from unlocks in list.xml and vehicle definition files.
+// Unlocked modules are looked up in modules, modules which are not defined in nation components are skipped.
+type techTreeParser struct {
+	vehicles *vehiclesParser
+	modules  *modulesParser
+
+	lock  *sync.Mutex
+	trees map[string]types.TechTree
+}
+
+func newTechTreeParser() *techTreeParser {
+	return &techTreeParser{
+		lock:  &sync.Mutex{},
+		trees: make(map[string]types.TechTree),
+	}
+}
+
+func (p *techTreeParser) DependsOn() []string {
+	return []string{"vehicles", "modules"}
+}
+
+func (p *techTreeParser) Link(name string, exporter assetExporter) error {
+	switch e := exporter.(type) {
+	case *vehiclesParser:
+		p.vehicles = e
+	case *modulesParser:
+		p.modules = e
+	default:
+		return errors.New("unexpected exporter for " + name)
+	}
+	return nil
+}
+
+func (p *techTreeParser) Exclusive() bool {
+	return false
+}
+
+func (p *techTreeParser) Match(path string) bool {
+	_, _, ok := matchVehicleFile(path)
+	return ok
+}
+
+func (p *techTreeParser) Parse(path string, r io.Reader) error {
+	nation, name, _ := matchVehicleFile(path)
+
+	root, err := decodeXMLTree(r)
+	if err != nil {
+		return err
+	}
+
+	var edges, moduleEdges []types.TechTreeUnlock
+	for vehicle, unlocks := range fileUnlocks(name, root) {
+		from, ok := p.vehicles.vehicleID(nation, vehicle)
+		if !ok {
+			continue
+		}
+		for _, unlock := range unlocks {
+			edge := types.TechTreeUnlock{From: from, Type: unlock.Type, Module: unlock.Module, Cost: unlock.Cost}
+			if unlock.Target != "vehicle" {
+				edge.To, ok = p.modules.moduleID(nation, unlock.Target, unlock.Name)
+				if ok {
+					moduleEdges = append(moduleEdges, edge)
+				}
+				continue
+			}
+			edge.To, ok = p.vehicles.vehicleID(nation, unlock.Name)
+			if ok {
+				edges = append(edges, edge)
+			}
+		}
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	tree := p.trees[nation]
+	for _, edge := range moduleEdges {
+		if !slices.Contains(tree.Modules, edge) {
+			tree.Modules = append(tree.Modules, edge)
+		}
+	}
+	for _, edge := range edges {
+		if !slices.Contains(tree.Edges, edge) {
+			tree.Edges = append(tree.Edges, edge)
+		}
+		for _, id := range []string{edge.From, edge.To} {
+			if !slices.Contains(tree.Nodes, id) {
+				tree.Nodes = append(tree.Nodes, id)
+			}
+		}
+	}
+	p.trees[nation] = tree
+	return nil
+}
+
+func (p *techTreeParser) Export(filePath string) (int, error) {
+	err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create path")
+	}
+
+	var nodes int
+	for nation, tree := range p.trees {
+		// a nation without any unlocks exports empty lists instead of null
+		if tree.Nodes == nil {
+			tree.Nodes = []string{}
+		}
+		if tree.Edges == nil {
+			tree.Edges = []types.TechTreeUnlock{}
+		}
+		if tree.Modules == nil {
+			tree.Modules = []types.TechTreeUnlock{}
+		}
+		slices.Sort(tree.Nodes)
+		for _, edges := range [][]types.TechTreeUnlock{tree.Edges, tree.Modules} {
+			slices.SortFunc(edges, func(a, b types.TechTreeUnlock) int {
+				return cmp.Or(cmp.Compare(a.From, b.From), cmp.Compare(a.To, b.To), cmp.Compare(a.Type, b.Type), cmp.Compare(a.Module, b.Module))
+			})
+		}
+		p.trees[nation] = tree
+		nodes += len(tree.Nodes)
+	}
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create file")
+	}
+	defer f.Close()
+
+	e := json.NewEncoder(f)
+	e.SetIndent("", "  ")
+	return nodes, e.Encode(p.trees)
+}
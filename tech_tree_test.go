@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/cufee/aftermath-assets/types"
+	"github.com/matryer/is"
+)
+
+func TestTechTree(t *testing.T) {
+	is := is.New(t)
+
+	fsys := fstest.MapFS{
+		"XML/item_defs/vehicles/ussr/list.xml": &fstest.MapFile{Data: []byte(`<root>
+	<A-20><id>4</id></A-20>
+	<T-34><id>0</id></T-34>
+	<T-34-85><id>1</id></T-34-85>
+	<KV-1><id>2</id></KV-1>
+</root>`)},
+		"XML/item_defs/vehicles/ussr/A-20.xml": &fstest.MapFile{Data: []byte(`<root>
+	<guns><_45mm_20K><unlocks><vehicle>T-34<cost>6000</cost></vehicle></unlocks></_45mm_20K></guns>
+</root>`)},
+		"XML/item_defs/vehicles/ussr/components/guns.xml": &fstest.MapFile{Data: []byte(testVehicleGuns)},
+		"XML/item_defs/vehicles/ussr/T-34.xml": &fstest.MapFile{Data: []byte(`<root>
+	<turrets0>
+		<T-34_turret_2>
+			<unlocks><vehicle>T-34-85<cost>20500</cost></vehicle></unlocks>
+			<guns><_57mm_ZiS-4><unlocks><vehicle>KV-1<cost>21000</cost></vehicle><gun>_76mm_F-34<cost>7000</cost></gun><radio>9R<cost>100</cost></radio></unlocks></_57mm_ZiS-4></guns>
+		</T-34_turret_2>
+	</turrets0>
+</root>`)},
+		"XML/item_defs/vehicles/germany/list.xml":  &fstest.MapFile{Data: []byte(`<root><Pz35t><id>1</id></Pz35t></root>`)},
+		"XML/item_defs/vehicles/germany/Pz35t.xml": &fstest.MapFile{Data: []byte(`<root></root>`)},
+	}
+
+	assets := parseTestAssets(t, fsys, "tech_tree")
+	trees := readTestAsset[map[string]types.TechTree](t, assets, "tech_tree.json")
+
+	tree := trees["ussr"]
+	is.Equal(tree.Nodes, []string{"1", "1025", "257", "513"})
+	is.Equal(len(tree.Edges), 3)
+	is.Equal(tree.Predecessors("257"), []types.TechTreeUnlock{{From: "1", To: "257", Type: "turret", Module: "T-34_turret_2", Cost: 20500}})
+	is.Equal(tree.Successors("1025"), []types.TechTreeUnlock{{From: "1025", To: "1", Type: "gun", Module: "_45mm_20K", Cost: 6000}})
+	is.Equal(tree.AllPredecessors("513"), []string{"1", "1025"})
+	is.Equal(tree.AllSuccessors("1025"), []string{"1", "257", "513"})
+	is.Equal(len(tree.AllSuccessors("257")), 0)
+
+	// modules missing in nation components, such as radios, are skipped
+	is.Equal(tree.Modules, []types.TechTreeUnlock{{From: "1", To: "772", Type: "gun", Module: "_57mm_ZiS-4", Cost: 7000}})
+
+	// a nation without unlocks has empty lists instead of null
+	empty := readTestAsset[map[string]map[string]any](t, assets, "tech_tree.json")["germany"]
+	is.Equal(empty, map[string]any{"nodes": []any{}, "edges": []any{}, "modules": []any{}})
+}
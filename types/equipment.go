@@ -0,0 +1,24 @@
+package types
+
+import "golang.org/x/text/language"
+
+// Equipment is a consumable or a provision
+type Equipment struct {
+	// ID is a global id encoded without a nation, see globalid.KindConsumable and globalid.KindProvision
+	ID                    string                  `json:"id"`
+	Key                   string                  `json:"key"`
+	Name                  string                  `json:"name"`
+	LocalizedNames        map[language.Tag]string `json:"names"`
+	LocalizedDescriptions map[language.Tag]string `json:"descriptions"`
+
+	// Cooldown and Duration are in seconds, zero when an item has none
+	Cooldown float64 `json:"cooldown,omitempty"`
+	Duration float64 `json:"duration,omitempty"`
+	// Effects are other numeric script parameters, keyed by name
+	Effects map[string]float64 `json:"effects,omitempty"`
+
+	Tags []string `json:"tags"`
+	// VehicleTags and ExcludedVehicleTags restrict vehicles an item can be used on, such as vehicle classes
+	VehicleTags         []string `json:"vehicleTags,omitempty"`
+	ExcludedVehicleTags []string `json:"excludedVehicleTags,omitempty"`
+}
@@ -0,0 +1,26 @@
+package types
+
+import "golang.org/x/text/language"
+
+type Module struct {
+	ID   string `json:"id"`
+	Key  string `json:"key"`
+	Type string `json:"type"`
+	// Name is the component name vehicle definitions reference this module by
+	Name           string                  `json:"name"`
+	Nation         string                  `json:"nation"`
+	LocalizedNames map[language.Tag]string `json:"names"`
+
+	Tier   int     `json:"tier"`
+	Weight float64 `json:"weight"`
+	Price  Price   `json:"price"`
+
+	// ReloadTime, AimTime and Dispersion are set for guns
+	ReloadTime float64 `json:"reloadTime,omitempty"`
+	AimTime    float64 `json:"aimTime,omitempty"`
+	Dispersion float64 `json:"dispersion,omitempty"`
+	// Power is set for engines
+	Power float64 `json:"power,omitempty"`
+	// Traverse is the rotation speed of a gun, turret or chassis in degrees per second
+	Traverse float64 `json:"traverse,omitempty"`
+}
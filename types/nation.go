@@ -0,0 +1,10 @@
+package types
+
+import "golang.org/x/text/language"
+
+type Nation struct {
+	ID             int                     `json:"id"`
+	Key            string                  `json:"key"`
+	Name           string                  `json:"name"`
+	LocalizedNames map[language.Tag]string `json:"names"`
+}
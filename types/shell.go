@@ -0,0 +1,38 @@
+package types
+
+import "golang.org/x/text/language"
+
+type Shell struct {
+	ID   string `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	// Type is one of AP, APCR, HE or HEAT, other shell kinds are exported as defined in game files
+	Type           string                  `json:"type"`
+	Nation         string                  `json:"nation"`
+	LocalizedNames map[language.Tag]string `json:"names"`
+
+	Caliber float64 `json:"caliber"`
+	Damage  int     `json:"damage"`
+	Price   Price   `json:"price"`
+
+	// Guns firing this shell, speed and penetration depend on a gun
+	Guns []ShellGun `json:"guns"`
+	// Vehicles are ids of vehicles with at least one of Guns
+	Vehicles []string `json:"vehicles"`
+}
+
+type ShellGun struct {
+	// ID is the global id of a shared gun, guns defined only in a vehicle definition do not have it
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+	// Vehicle is set when a vehicle definition has its own shots for the gun, they override shots of a shared gun
+	Vehicle     string             `json:"vehicle,omitempty"`
+	Speed       float64            `json:"speed"`
+	Penetration []ShellPenetration `json:"penetration"`
+}
+
+// ShellPenetration is penetration in millimeters at a distance in meters
+type ShellPenetration struct {
+	Distance int     `json:"distance"`
+	Value    float64 `json:"value"`
+}
@@ -0,0 +1,73 @@
+package types
+
+import "slices"
+
+// TechTree is a research graph of a nation, nodes are vehicle ids
+type TechTree struct {
+	Nodes []string         `json:"nodes"`
+	Edges []TechTreeUnlock `json:"edges"`
+	// Modules are edges from a vehicle to modules researched on it, To is a global id of a module
+	Modules []TechTreeUnlock `json:"modules"`
+}
+
+// TechTreeUnlock is an edge from a vehicle to a vehicle or a module researched from it
+type TechTreeUnlock struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Type is the type of a module unlocking a vehicle or a module, such as gun or turret, or vehicle when no module is required
+	Type   string `json:"type"`
+	Module string `json:"module,omitempty"`
+	Cost   int    `json:"cost"`
+}
+
+// Predecessors returns edges to a vehicle
+func (t TechTree) Predecessors(id string) []TechTreeUnlock {
+	var edges []TechTreeUnlock
+	for _, edge := range t.Edges {
+		if edge.To == id {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+// Successors returns edges from a vehicle
+func (t TechTree) Successors(id string) []TechTreeUnlock {
+	var edges []TechTreeUnlock
+	for _, edge := range t.Edges {
+		if edge.From == id {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+// AllPredecessors returns ids of all vehicles which need to be researched before a vehicle, closest vehicles first
+func (t TechTree) AllPredecessors(id string) []string {
+	return t.walk(id, func(edge TechTreeUnlock) (string, string) { return edge.To, edge.From })
+}
+
+// AllSuccessors returns ids of all vehicles which can be researched after a vehicle, closest vehicles first
+func (t TechTree) AllSuccessors(id string) []string {
+	return t.walk(id, func(edge TechTreeUnlock) (string, string) { return edge.From, edge.To })
+}
+
+// walk visits vehicles breadth first, direction returns the current and the next vehicle of an edge
+func (t TechTree) walk(id string, direction func(edge TechTreeUnlock) (string, string)) []string {
+	var visited []string
+	queue := []string{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, edge := range t.Edges {
+			from, to := direction(edge)
+			if from != current || to == id || slices.Contains(visited, to) {
+				continue
+			}
+			visited = append(visited, to)
+			queue = append(queue, to)
+		}
+	}
+	return visited
+}
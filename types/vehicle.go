@@ -2,11 +2,17 @@ package types
 
 import "golang.org/x/text/language"
 
-
 type Vehicle struct {
-	ID             string                  `json:"id"`
-	Key            string                  `json:"key"`
-	LocalizedNames map[language.Tag]string `json:"names"`
+	ID string `json:"id"`
+	// Key is the short name localization key of a vehicle, or the full name key when a vehicle has no short name
+	Key string `json:"key"`
+	// NameKey is the full name localization key of a vehicle
+	NameKey string `json:"nameKey"`
+
+	// LocalizedNames are full names, vehicles without a short name have the same value in LocalizedShortNames
+	LocalizedNames        map[language.Tag]string `json:"names"`
+	LocalizedShortNames   map[language.Tag]string `json:"shortNames"`
+	LocalizedDescriptions map[language.Tag]string `json:"descriptions"`
 
 	Tier        int    `json:"tier"`
 	Class       string `json:"class"`
@@ -14,4 +20,56 @@ type Vehicle struct {
 	Premium     bool   `json:"premium"`
 	SuperTest   bool   `json:"superTest"`
 	Collectible bool   `json:"collectible"`
+
+	// Tags are all tags of a vehicle from the game files, in the order they are listed
+	Tags []string `json:"tags"`
+	// Role is derived from a role tag, such as role_HT_assault, and is empty for vehicles without a role
+	Role VehicleRole `json:"role,omitempty"`
+	// LocalizedRoleNames are names of the vehicle role from the role_<role> strings
+	LocalizedRoleNames map[language.Tag]string `json:"roleNames,omitempty"`
+
+	Price VehiclePrice `json:"price"`
+}
+
+// VehicleRole is a vehicle role tag without the role_ prefix
+type VehicleRole string
+
+const (
+	RoleHeavyAssault    VehicleRole = "HT_assault"
+	RoleHeavyBreak      VehicleRole = "HT_break"
+	RoleHeavySupport    VehicleRole = "HT_support"
+	RoleHeavyUniversal  VehicleRole = "HT_universal"
+	RoleMediumAssault   VehicleRole = "MT_assault"
+	RoleMediumSniper    VehicleRole = "MT_sniper"
+	RoleMediumSupport   VehicleRole = "MT_support"
+	RoleMediumUniversal VehicleRole = "MT_universal"
+	RoleLightUniversal  VehicleRole = "LT_universal"
+	RoleLightWheeled    VehicleRole = "LT_wheeled"
+	RoleATSPGAssault    VehicleRole = "ATSPG_assault"
+	RoleATSPGSniper     VehicleRole = "ATSPG_sniper"
+	RoleATSPGSupport    VehicleRole = "ATSPG_support"
+	RoleATSPGUniversal  VehicleRole = "ATSPG_universal"
+)
+
+// VehicleRoles are all known vehicle roles, a vehicle with any other role tag fails to parse
+var VehicleRoles = []VehicleRole{
+	RoleHeavyAssault, RoleHeavyBreak, RoleHeavySupport, RoleHeavyUniversal,
+	RoleMediumAssault, RoleMediumSniper, RoleMediumSupport, RoleMediumUniversal,
+	RoleLightUniversal, RoleLightWheeled,
+	RoleATSPGAssault, RoleATSPGSniper, RoleATSPGSupport, RoleATSPGUniversal,
+}
+
+// Price is an amount in a currency, such as credits or gold
+type Price struct {
+	Amount   int    `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type VehiclePrice struct {
+	Amount int `json:"amount"`
+	// Currency is credits, gold or any other currency used by the game files, such as an event currency
+	Currency string `json:"currency"`
+	// ResearchXP is the lowest experience cost to research a vehicle from one of the previous vehicles, zero for vehicles not in a tech tree
+	ResearchXP int  `json:"researchXp"`
+	NotInShop  bool `json:"notInShop"`
 }
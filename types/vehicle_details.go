@@ -0,0 +1,64 @@
+package types
+
+type VehicleDetails struct {
+	ID     string `json:"id"`
+	Key    string `json:"key"`
+	Nation string `json:"nation"`
+
+	Hull        VehicleHull        `json:"hull"`
+	SpeedLimits VehicleSpeedLimits `json:"speedLimits"`
+
+	Chassis []VehicleModule `json:"chassis"`
+	Turrets []VehicleTurret `json:"turrets"`
+	Engines []VehicleModule `json:"engines"`
+
+	// Stock is a configuration with the lowest level module of every type, Top is a configuration with the highest level ones
+	Stock VehicleConfiguration `json:"stock"`
+	Top   VehicleConfiguration `json:"top"`
+}
+
+type VehicleArmor struct {
+	Front float64 `json:"front"`
+	Side  float64 `json:"side"`
+	Rear  float64 `json:"rear"`
+}
+
+type VehicleHull struct {
+	Health int          `json:"health"`
+	Weight float64      `json:"weight"`
+	Armor  VehicleArmor `json:"armor"`
+}
+
+type VehicleSpeedLimits struct {
+	Forward  float64 `json:"forward"`
+	Backward float64 `json:"backward"`
+}
+
+type VehicleModule struct {
+	Key   string `json:"key"`
+	Level int    `json:"level"`
+	// Weight of a shared module is taken from the module catalogue, unless the vehicle file overrides it
+	Weight float64 `json:"weight,omitempty"`
+}
+
+type VehicleTurret struct {
+	VehicleModule
+	Health    int             `json:"health"`
+	ViewRange float64         `json:"viewRange"`
+	Armor     VehicleArmor    `json:"armor"`
+	Guns      []VehicleModule `json:"guns"`
+}
+
+type VehicleConfiguration struct {
+	Chassis string `json:"chassis"`
+	Turret  string `json:"turret"`
+	Gun     string `json:"gun"`
+	Engine  string `json:"engine"`
+
+	// Health is the sum of hull and turret health
+	Health    int     `json:"health"`
+	ViewRange float64 `json:"viewRange"`
+	// Weight is the total weight of the hull and all modules, it is not set when a module has an unknown weight
+	Weight      float64      `json:"weight,omitempty"`
+	TurretArmor VehicleArmor `json:"turretArmor"`
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/cufee/aftermath-assets/globalid"
+	"github.com/cufee/aftermath-assets/types"
+)
+
+// unlockModuleTypes are elements listing modules of a type in a vehicle definition
+var unlockModuleTypes = map[string]string{
+	"chassis":   "chassis",
+	"turrets0":  "turret",
+	"guns":      "gun",
+	"engines":   "engine",
+	"radios":    "radio",
+	"fuelTanks": "fuelTank",
+}
+
+// vehicleUnlock is an edge of a tech tree, a vehicle or a module is researched from a module of the previous vehicle
+type vehicleUnlock struct {
+	// Target is vehicle or the type of an unlocked module, such as gun
+	Target string
+	// Name is the name of a definition file of an unlocked vehicle in the same nation, or the component name of a module
+	Name string
+	Cost int
+	// Type is the type of a module unlocking a vehicle, or vehicle when a vehicle is unlocked without a module
+	Type   string
+	Module string
+}
+
+// matchVehicleFile returns a nation and a vehicle name of a vehicle definition file, the name of a nation list.xml file is list
+func matchVehicleFile(path string) (nation string, name string, ok bool) {
+	match := vehicleDefinitionRegex.FindStringSubmatch(path)
+	if match == nil {
+		return "", "", false
+	}
+	if _, ok := globalid.NationID(match[2]); !ok {
+		return "", "", false
+	}
+	return match[2], match[3], true
+}
+
+// isUnlockTarget checks if an element in unlocks is a vehicle or a module
+func isUnlockTarget(name string) bool {
+	if name == "vehicle" {
+		return true
+	}
+	for _, moduleType := range unlockModuleTypes {
+		if moduleType == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fileUnlocks returns unlocks of a vehicle definition or list.xml file, keyed by the name of a vehicle unlocks belong to
+func fileUnlocks(name string, root *xmlNode) map[string][]vehicleUnlock {
+	unlocks := make(map[string][]vehicleUnlock)
+	if name != "list" {
+		unlocks[name] = vehicleUnlocks(root, "vehicle", "")
+		return unlocks
+	}
+	for _, vehicle := range root.Children {
+		unlocks[vehicle.Name] = vehicleUnlocks(vehicle, "vehicle", "")
+	}
+	return unlocks
+}
+
+// vehicleUnlocks returns all vehicles and modules unlocked by a vehicle or its modules
+func vehicleUnlocks(node *xmlNode, moduleType string, module string) []vehicleUnlock {
+	var unlocks []vehicleUnlock
+	for _, child := range node.Children {
+		if childType, ok := unlockModuleTypes[child.Name]; ok {
+			for _, m := range child.Children {
+				unlocks = append(unlocks, vehicleUnlocks(m, childType, m.Name)...)
+			}
+			continue
+		}
+		if child.Name != "unlocks" {
+			unlocks = append(unlocks, vehicleUnlocks(child, moduleType, module)...)
+			continue
+		}
+
+		for _, unlock := range child.Children {
+			fields := strings.Fields(unlock.Text)
+			if !isUnlockTarget(unlock.Name) || len(fields) == 0 {
+				continue
+			}
+			// names can be prefixed with a nation
+			_, name, found := strings.Cut(fields[0], ":")
+			if !found {
+				name = fields[0]
+			}
+			unlocks = append(unlocks, vehicleUnlock{Target: unlock.Name, Name: name, Cost: unlock.Int("cost"), Type: moduleType, Module: module})
+		}
+	}
+	return unlocks
+}
+
+// vehicleUnlocksParser sets the research cost of vehicles unlocked from a vehicle
+type vehicleUnlocksParser struct {
+	vehicles   map[string]types.Vehicle
+	vehicleIDs map[string]string
+	lock       *sync.Mutex
+}
+
+func (p *vehicleUnlocksParser) DependsOn() []string {
+	return []string{"vehicles"}
+}
+func (p *vehicleUnlocksParser) Exclusive() bool {
+	return false
+}
+func (p *vehicleUnlocksParser) Match(path string) bool {
+	_, _, ok := matchVehicleFile(path)
+	return ok
+}
+func (p *vehicleUnlocksParser) Parse(path string, r io.Reader) error {
+	nation, name, _ := matchVehicleFile(path)
+
+	root, err := decodeXMLTree(r)
+	if err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, unlocks := range fileUnlocks(name, root) {
+		for _, unlock := range unlocks {
+			id, ok := p.vehicleIDs[nation+"/"+unlock.Name]
+			if unlock.Target != "vehicle" || !ok || unlock.Cost <= 0 {
+				continue
+			}
+			vehicle := p.vehicles[id]
+			if vehicle.Price.ResearchXP == 0 || unlock.Cost < vehicle.Price.ResearchXP {
+				vehicle.Price.ResearchXP = unlock.Cost
+			}
+			p.vehicles[id] = vehicle
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/cufee/aftermath-assets/types"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registerAsset("vehicle_details", func() asset {
+		details := newVehicleDetailsParser()
+		return asset{File: "vehicle_details.json", Parsers: map[string]parseFunc{"vehicle_details": details}, Exporter: details}
+	})
+}
+
+// vehicleDefinitionRegex matches XML/item_defs/vehicles/<nation>/<name>.xml, nation list.xml files are matched as well and need to be skipped
+var vehicleDefinitionRegex = regexp.MustCompile(`(^|/)XML/item_defs/vehicles/([^/]+)/([^/]+)\.xml$`)
+
+// matchVehicleDefinition returns a nation and a vehicle name of a vehicle definition file
+func matchVehicleDefinition(path string) (nation string, name string, ok bool) {
+	nation, name, ok = matchVehicleFile(path)
+	if !ok || name == "list" {
+		return "", "", false
+	}
+	return nation, name, true
+}
+
+// vehicleDetailsParser parses vehicle definition files, a vehicle id is looked up by the file name in vehicles.
+// Modules marked as shared are defined in nation components, their weight and level are looked up in modules.
+type vehicleDetailsParser struct {
+	vehicles *vehiclesParser
+	modules  *modulesParser
+
+	lock    *sync.Mutex
+	details map[string]types.VehicleDetails
+}
+
+func newVehicleDetailsParser() *vehicleDetailsParser {
+	return &vehicleDetailsParser{
+		lock:    &sync.Mutex{},
+		details: make(map[string]types.VehicleDetails),
+	}
+}
+
+func (p *vehicleDetailsParser) DependsOn() []string {
+	return []string{"vehicles", "modules"}
+}
+
+func (p *vehicleDetailsParser) Link(name string, exporter assetExporter) error {
+	switch e := exporter.(type) {
+	case *vehiclesParser:
+		p.vehicles = e
+	case *modulesParser:
+		p.modules = e
+	default:
+		return errors.New("unexpected exporter for " + name)
+	}
+	return nil
+}
+
+func (p *vehicleDetailsParser) Exclusive() bool {
+	return false
+}
+
+func (p *vehicleDetailsParser) Match(path string) bool {
+	_, _, ok := matchVehicleDefinition(path)
+	return ok
+}
+
+func (p *vehicleDetailsParser) Parse(filePath string, r io.Reader) error {
+	nation, name, _ := matchVehicleDefinition(filePath)
+
+	id, ok := p.vehicles.vehicleID(nation, name)
+	if !ok {
+		// files of removed vehicles are still shipped with the game
+		return nil
+	}
+
+	root, err := decodeXMLTree(r)
+	if err != nil {
+		return err
+	}
+
+	modules := vehicleModules{nation: nation, catalogue: p.modules, unknownWeight: make(map[string]bool)}
+	details := types.VehicleDetails{
+		ID:     id,
+		Key:    name,
+		Nation: nation,
+		Hull: types.VehicleHull{
+			Health: root.Int("hull/maxHealth"),
+			Weight: root.Float("hull/weight"),
+			Armor:  primaryArmor(root.Child("hull")),
+		},
+		SpeedLimits: types.VehicleSpeedLimits{
+			Forward:  root.Float("speedLimits/forward"),
+			Backward: root.Float("speedLimits/backward"),
+		},
+		Chassis: modules.list("chassis", root.Child("chassis")),
+		Engines: modules.list("engine", root.Child("engines")),
+	}
+	if turrets := root.Child("turrets0"); turrets != nil {
+		for _, turret := range turrets.Children {
+			details.Turrets = append(details.Turrets, types.VehicleTurret{
+				VehicleModule: modules.module("turret", turret),
+				Health:        turret.Int("maxHealth"),
+				ViewRange:     turret.Float("circularVisionRadius"),
+				Armor:         primaryArmor(turret),
+				Guns:          modules.list("gun", turret.Child("guns")),
+			})
+		}
+	}
+	if len(details.Chassis) == 0 || len(details.Turrets) == 0 || len(details.Engines) == 0 {
+		return errors.New("vehicle definition is missing modules")
+	}
+
+	details.Stock = modules.configuration(details, false)
+	details.Top = modules.configuration(details, true)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.details[id] = details
+	return nil
+}
+
+func (p *vehicleDetailsParser) Export(filePath string) (int, error) {
+	err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create path")
+	}
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create file")
+	}
+	defer f.Close()
+
+	e := json.NewEncoder(f)
+	e.SetIndent("", "  ")
+	return len(p.details), e.Encode(p.details)
+}
+
+// vehicleModules reads modules of a vehicle definition, modules marked as shared are looked up in the catalogue
+type vehicleModules struct {
+	nation    string
+	catalogue *modulesParser
+	// unknownWeight are keys of shared modules missing in the catalogue, prefixed with the module type
+	unknownWeight map[string]bool
+}
+
+func (m vehicleModules) module(moduleType string, node *xmlNode) types.VehicleModule {
+	module := types.VehicleModule{Key: node.Name, Level: node.Int("level"), Weight: node.Float("weight")}
+	if fields := strings.Fields(node.Text); len(fields) == 0 || fields[0] != "shared" {
+		return module
+	}
+
+	shared, ok := m.catalogue.module(m.nation, moduleType, node.Name)
+	if !ok {
+		if module.Weight == 0 {
+			m.unknownWeight[moduleType+"/"+node.Name] = true
+		}
+		return module
+	}
+	if module.Level == 0 {
+		module.Level = shared.Tier
+	}
+	if module.Weight == 0 {
+		module.Weight = shared.Weight
+	}
+	return module
+}
+
+func (m vehicleModules) list(moduleType string, node *xmlNode) []types.VehicleModule {
+	if node == nil {
+		return nil
+	}
+	var modules []types.VehicleModule
+	for _, child := range node.Children {
+		modules = append(modules, m.module(moduleType, child))
+	}
+	return modules
+}
+
+// primaryArmor resolves front, side and rear armor names listed in primaryArmor to values defined in armor
+func primaryArmor(node *xmlNode) types.VehicleArmor {
+	if node == nil {
+		return types.VehicleArmor{}
+	}
+
+	var values []float64
+	for _, name := range strings.Fields(node.Value("primaryArmor")) {
+		values = append(values, node.Float("armor/"+name))
+	}
+	for len(values) < 3 {
+		values = append(values, 0)
+	}
+	return types.VehicleArmor{Front: values[0], Side: values[1], Rear: values[2]}
+}
+
+// configuration builds a configuration from modules with the lowest level of every type, or the highest one for top.
+// Modules with the same level are picked in the order of the definition file, the first one for stock and the last one for top.
+// Weight is not set when the weight of a module is unknown.
+func (m vehicleModules) configuration(details types.VehicleDetails, top bool) types.VehicleConfiguration {
+	turrets := make([]types.VehicleModule, len(details.Turrets))
+	for i, turret := range details.Turrets {
+		turrets[i] = turret.VehicleModule
+	}
+	turret := details.Turrets[moduleByLevel(turrets, top)]
+	chassis := details.Chassis[moduleByLevel(details.Chassis, top)]
+	engine := details.Engines[moduleByLevel(details.Engines, top)]
+	var gun types.VehicleModule
+	if len(turret.Guns) > 0 {
+		gun = turret.Guns[moduleByLevel(turret.Guns, top)]
+	}
+
+	configuration := types.VehicleConfiguration{
+		Chassis: chassis.Key,
+		Turret:  turret.Key,
+		Gun:     gun.Key,
+		Engine:  engine.Key,
+
+		Health:      details.Hull.Health + turret.Health,
+		ViewRange:   turret.ViewRange,
+		TurretArmor: turret.Armor,
+	}
+	if !m.unknownWeight["chassis/"+chassis.Key] && !m.unknownWeight["turret/"+turret.Key] && !m.unknownWeight["gun/"+gun.Key] && !m.unknownWeight["engine/"+engine.Key] {
+		configuration.Weight = details.Hull.Weight + chassis.Weight + turret.Weight + gun.Weight + engine.Weight
+	}
+	return configuration
+}
+
+// moduleByLevel returns an index of a module with the lowest level, or the highest one when top is set
+func moduleByLevel(modules []types.VehicleModule, top bool) int {
+	index := 0
+	for i, module := range modules {
+		if top && module.Level >= modules[index].Level || !top && module.Level < modules[index].Level {
+			index = i
+		}
+	}
+	return index
+}
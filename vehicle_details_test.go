@@ -0,0 +1,129 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/cufee/aftermath-assets/types"
+	"github.com/matryer/is"
+)
+
+const testVehicleList = `<root>
+	<T-34>
+		<id>0</id>
+		<userString>#ussr_vehicles:T-34</userString>
+		<tags>mediumTank</tags>
+		<level>5</level>
+		<price>1000</price>
+	</T-34>
+</root>`
+
+const testVehicleDefinition = `<root>
+	<speedLimits>
+		<forward>54.0</forward>
+		<backward>20.0</backward>
+	</speedLimits>
+	<hull>
+		<armor>
+			<armor_1>45</armor_1>
+			<armor_2>40 <vehicleDamageFactor>0</vehicleDamageFactor></armor_2>
+			<armor_3>40</armor_3>
+		</armor>
+		<primaryArmor>armor_1 armor_2 armor_3</primaryArmor>
+		<weight>13500</weight>
+		<maxHealth>330</maxHealth>
+	</hull>
+	<chassis>
+		<T-34_chassis_1><level>4</level><weight>8000</weight></T-34_chassis_1>
+		<T-34_chassis_2><level>5</level><weight>8500</weight></T-34_chassis_2>
+	</chassis>
+	<turrets0>
+		<T-34_turret_1>
+			<level>4</level>
+			<maxHealth>110</maxHealth>
+			<circularVisionRadius>270</circularVisionRadius>
+			<weight>2500</weight>
+			<armor><armor_1>52</armor_1></armor>
+			<primaryArmor>armor_1 armor_1 armor_1</primaryArmor>
+			<guns>
+				<_76mm_L-11>shared</_76mm_L-11>
+			</guns>
+		</T-34_turret_1>
+		<T-34_turret_2>
+			<level>5</level>
+			<maxHealth>130</maxHealth>
+			<circularVisionRadius>280</circularVisionRadius>
+			<weight>3000</weight>
+			<guns>
+				<_57mm_ZiS-4>shared<weight>1000</weight></_57mm_ZiS-4>
+				<_76mm_F-34>shared</_76mm_F-34>
+			</guns>
+		</T-34_turret_2>
+	</turrets0>
+	<engines>
+		<V-2>shared</V-2>
+		<V-2-34>shared<level>7</level></V-2-34>
+	</engines>
+</root>`
+
+const testVehicleGuns = `<root>
+	<shared>
+		<_76mm_L-11><id>1</id><level>4</level><weight>1270</weight></_76mm_L-11>
+		<_57mm_ZiS-4><id>2</id><level>6</level><weight>1200</weight></_57mm_ZiS-4>
+		<_76mm_F-34><id>3</id><level>5</level><weight>1550</weight></_76mm_F-34>
+	</shared>
+</root>`
+
+const testVehicleEngines = `<root>
+	<shared>
+		<V-2><id>1</id><level>6</level><weight>750</weight></V-2>
+	</shared>
+</root>`
+
+func TestVehicleDetails(t *testing.T) {
+	is := is.New(t)
+
+	fsys := fstest.MapFS{
+		"XML/item_defs/vehicles/ussr/list.xml":               &fstest.MapFile{Data: []byte(testVehicleList)},
+		"XML/item_defs/vehicles/ussr/T-34.xml":               &fstest.MapFile{Data: []byte(testVehicleDefinition)},
+		"XML/item_defs/vehicles/ussr/components/guns.xml":    &fstest.MapFile{Data: []byte(testVehicleGuns)},
+		"XML/item_defs/vehicles/ussr/components/engines.xml": &fstest.MapFile{Data: []byte(testVehicleEngines)},
+		"XML/item_defs/vehicles/ussr/Old-1.xml":              &fstest.MapFile{Data: []byte("<root/>")},
+		"XML/item_defs/vehicles/common/crew.xml":             &fstest.MapFile{Data: []byte("<root/>")},
+	}
+
+	assets := parseTestAssets(t, fsys, "vehicle_details")
+	details := readTestAsset[map[string]types.VehicleDetails](t, assets, "vehicle_details.json")
+
+	is.Equal(len(details), 1)
+	_, err := os.Stat(filepath.Join(assets, "vehicles.json"))
+	is.True(os.IsNotExist(err)) // dependencies are not exported
+
+	t34 := details["1"]
+	is.Equal(t34.Key, "T-34")
+	is.Equal(t34.Hull.Armor, types.VehicleArmor{Front: 45, Side: 40, Rear: 40})
+	is.Equal(t34.SpeedLimits.Forward, 54.0)
+	is.Equal(len(t34.Turrets), 2)
+	is.Equal(t34.Turrets[1].Guns, []types.VehicleModule{{Key: "_57mm_ZiS-4", Level: 6, Weight: 1000}, {Key: "_76mm_F-34", Level: 5, Weight: 1550}})
+
+	// weight of shared modules comes from the module catalogue
+	is.Equal(t34.Stock, types.VehicleConfiguration{
+		Chassis:     "T-34_chassis_1",
+		Turret:      "T-34_turret_1",
+		Gun:         "_76mm_L-11",
+		Engine:      "V-2",
+		Health:      440,
+		ViewRange:   270,
+		Weight:      13500 + 8000 + 2500 + 1270 + 750,
+		TurretArmor: types.VehicleArmor{Front: 52, Side: 52, Rear: 52},
+	})
+
+	// top modules have the highest level, regardless of their order
+	is.Equal(t34.Top.Chassis, "T-34_chassis_2")
+	is.Equal(t34.Top.Gun, "_57mm_ZiS-4")
+	is.Equal(t34.Top.Engine, "V-2-34")
+	is.Equal(t34.Top.Health, 460)
+	is.Equal(t34.Top.Weight, 0.0) // V-2-34 is missing in the catalogue
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"bytes"
 	"encoding/json"
-	"encoding/xml"
 	"os"
 	"sort"
 	"strconv"
@@ -16,51 +15,83 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/cufee/aftermath-assets/globalid"
 	"github.com/cufee/aftermath-assets/types"
 	"github.com/pkg/errors"
 	"golang.org/x/text/language"
 )
 
+func init() {
+	registerAsset("vehicles", func() asset {
+		vehicles := newVehiclesParser()
+		return asset{File: "vehicles.json", Parsers: map[string]parseFunc{"vehicles": vehicles.Items(), "vehicles.strings": vehicles.Strings(), "vehicles.unlocks": vehicles.Unlocks()}, Exporter: vehicles}
+	})
+}
+
 type vehiclesParser struct {
-	vehicleNames map[string]map[language.Tag]string
-	vehicles     map[string]types.Vehicle
-	lock         *sync.Mutex
+	vehicleNames        map[string]map[language.Tag]string
+	vehicleShortNames   map[string]map[language.Tag]string
+	vehicleDescriptions map[string]map[language.Tag]string
+	vehicleRoleNames    map[string]map[language.Tag]string
+	// vehicleKeys are localization keys of vehicles, keyed by vehicle id
+	vehicleKeys map[string]vehicleStringKeys
+	vehicles    map[string]types.Vehicle
+	// vehicleIDs are keyed by nation and the name of a vehicle definition file, <nation>/<name>
+	vehicleIDs map[string]string
+	lock       *sync.Mutex
 }
 
 func newVehiclesParser() *vehiclesParser {
 	return &vehiclesParser{
-		lock:         &sync.Mutex{},
-		vehicles:     make(map[string]types.Vehicle),
-		vehicleNames: make(map[string]map[language.Tag]string),
+		lock:        &sync.Mutex{},
+		vehicles:    make(map[string]types.Vehicle),
+		vehicleIDs:  make(map[string]string),
+		vehicleKeys: make(map[string]vehicleStringKeys),
+
+		vehicleNames:        make(map[string]map[language.Tag]string),
+		vehicleShortNames:   make(map[string]map[language.Tag]string),
+		vehicleDescriptions: make(map[string]map[language.Tag]string),
+		vehicleRoleNames:    make(map[string]map[language.Tag]string),
 	}
 }
 
 func (p *vehiclesParser) Items() *vehicleItemsParser {
-	return &vehicleItemsParser{vehicles: p.vehicles, lock: p.lock}
+	return &vehicleItemsParser{vehicles: p.vehicles, vehicleIDs: p.vehicleIDs, vehicleKeys: p.vehicleKeys, lock: p.lock}
+}
+
+// vehicleID returns a global id of a vehicle defined in XML/item_defs/vehicles/<nation>/<name>.xml
+func (p *vehiclesParser) vehicleID(nation, name string) (string, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	id, ok := p.vehicleIDs[nation+"/"+name]
+	return id, ok
+}
+func (p *vehiclesParser) Unlocks() *vehicleUnlocksParser {
+	return &vehicleUnlocksParser{vehicles: p.vehicles, vehicleIDs: p.vehicleIDs, lock: p.lock}
 }
 func (p *vehiclesParser) Strings() *vehicleStringsParser {
-	return &vehicleStringsParser{p.vehicleNames, p.vehicles, p.lock}
+	return &vehicleStringsParser{
+		vehicleNames:        p.vehicleNames,
+		vehicleShortNames:   p.vehicleShortNames,
+		vehicleDescriptions: p.vehicleDescriptions,
+		vehicleRoleNames:    p.vehicleRoleNames,
+		vehicleKeys:         p.vehicleKeys,
+		lock:                p.lock,
+	}
 }
-func (p *vehiclesParser) Export(filePath string) error {
+func (p *vehiclesParser) Export(filePath string) (int, error) {
 	err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
 	if err != nil {
-		return errors.Wrap(err, "failed to create path")
+		return 0, errors.Wrap(err, "failed to create path")
 	}
 
 	var keys []string
 	vehicles := make(map[string]types.Vehicle)
 	for key, vehicle := range p.vehicles {
-		names := p.vehicleNames[key]
-
-		// reduce the size
-		nameEnglish := names[language.English]
-		for tag, name := range names {
-			if name == nameEnglish && tag != language.English {
-				delete(names, tag)
-			}
-		}
-
-		vehicle.LocalizedNames = names
+		vehicle.LocalizedNames = reduceLocalizedNames(localizedOrEmpty(p.vehicleNames[key]))
+		vehicle.LocalizedShortNames = reduceLocalizedNames(localizedOrEmpty(p.vehicleShortNames[key]))
+		vehicle.LocalizedDescriptions = reduceLocalizedNames(localizedOrEmpty(p.vehicleDescriptions[key]))
+		vehicle.LocalizedRoleNames = reduceLocalizedNames(p.vehicleRoleNames[key])
 		vehicles[vehicle.ID] = vehicle
 		keys = append(keys, vehicle.ID)
 	}
@@ -73,76 +104,69 @@ func (p *vehiclesParser) Export(filePath string) error {
 
 	f, err := os.Create(filePath)
 	if err != nil {
-		return errors.Wrap(err, "failed to create file")
+		return 0, errors.Wrap(err, "failed to create file")
 	}
 
 	e := json.NewEncoder(f)
 	e.SetIndent("", "  ")
 	err = e.Encode(vehiclesSorted)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return len(vehiclesSorted), nil
 }
 
-// parseGoldPriceVehicles parses raw XML to find vehicles whose <price> contains
-// a <gold/> sub-element. These are premium/collectible vehicles.
-func parseGoldPriceVehicles(raw []byte) map[string]bool {
-	result := make(map[string]bool)
-	decoder := xml.NewDecoder(bytes.NewReader(raw))
-
-	var currentVehicle string
-	var inPrice bool
-	var depth int // depth inside <root>
+// parseVehiclePrices parses raw XML to find a price of every vehicle. An amount is the text of <price>,
+// a currency is the name of its sub-element, such as <gold/>, which the mxj library strips.
+func parseVehiclePrices(raw []byte) (map[string]types.VehiclePrice, error) {
+	root, err := decodeXMLTree(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
 
-	for {
-		tok, err := decoder.Token()
-		if err != nil {
-			break
-		}
-		switch t := tok.(type) {
-		case xml.StartElement:
-			if t.Name.Local == "root" {
-				continue
-			}
-			if depth == 0 {
-				// direct child of <root> = vehicle name
-				currentVehicle = t.Name.Local
-				depth = 1
-			} else if depth == 1 && t.Name.Local == "price" {
-				inPrice = true
-			} else if inPrice && t.Name.Local == "gold" {
-				result[currentVehicle] = true
-			}
-		case xml.EndElement:
-			if t.Name.Local == "root" {
-				continue
-			}
-			if t.Name.Local == currentVehicle {
-				currentVehicle = ""
-				depth = 0
-				inPrice = false
-			} else if t.Name.Local == "price" {
-				inPrice = false
-			}
+	prices := make(map[string]types.VehiclePrice)
+	for _, vehicle := range root.Children {
+		price := itemPrice(vehicle)
+		prices[vehicle.Name] = types.VehiclePrice{
+			Amount:    price.Amount,
+			Currency:  price.Currency,
+			NotInShop: vehicle.Value("notInShop") == "true",
 		}
 	}
+	return prices, nil
+}
 
-	return result
+// itemPrice returns a price from <price> of an item, the currency is credits unless <price> has a sub-element, such as <gold/>
+func itemPrice(item *xmlNode) types.Price {
+	price := types.Price{Amount: item.Int("price"), Currency: "credits"}
+	if node := item.Child("price"); node != nil && len(node.Children) > 0 {
+		price.Currency = node.Children[0].Name
+	}
+	return price
 }
 
-var vehicleItemsRegex = regexp.MustCompile(".*/XML/item_defs/vehicles/.*list.xml")
+var vehicleItemsRegex = regexp.MustCompile("(^|/)XML/item_defs/vehicles/.*list.xml")
 
 type vehicleItemsParser struct {
-	vehicles map[string]types.Vehicle
-	lock     *sync.Mutex
+	vehicles    map[string]types.Vehicle
+	vehicleIDs  map[string]string
+	vehicleKeys map[string]vehicleStringKeys
+	lock        *sync.Mutex
+}
+
+type vehicleStringKeys struct {
+	name        string
+	shortName   string
+	description string
+	role        string
 }
 
 type vehicleItem struct {
 	ID           string `xml:"id" json:"id"`
 	Name         string `xml:"userString" json:"userString"`
 	NameShort    string `xml:"shortUserString" json:"shortUserString"`
+	Description  string `xml:"description" json:"description"`
 	Tags         string `xml:"tags" json:"tags"`
 	Level        string `xml:"level" json:"level"`
 	Environments string `xml:"configurationModes" json:"configurationModes"`
@@ -151,7 +175,8 @@ type vehicleItem struct {
 	level        int
 	tags         []string
 	environments []string
-	premium      bool
+	price        types.VehiclePrice
+	vehicleRole  types.VehicleRole
 }
 
 var vehicleClasses = []string{"AT-SPG", "lightTank", "mediumTank", "heavyTank"}
@@ -165,6 +190,22 @@ func (item vehicleItem) class() string {
 	return "unknown"
 }
 
+// vehicleRolePrefix starts tags of vehicle roles, such as role_HT_assault
+const vehicleRolePrefix = "role_"
+
+// role returns a vehicle role without the tag prefix, a role tag which is not in types.VehicleRoles is an error
+func (item vehicleItem) role() (types.VehicleRole, error) {
+	for _, tag := range item.tags {
+		if role, ok := strings.CutPrefix(tag, vehicleRolePrefix); ok {
+			if !slices.Contains(types.VehicleRoles, types.VehicleRole(role)) {
+				return "", errors.Errorf("unknown vehicle role %s", tag)
+			}
+			return types.VehicleRole(role), nil
+		}
+	}
+	return "", nil
+}
+
 func (item vehicleItem) toVehicle(id, nation string) types.Vehicle {
 	key := item.Name
 	if item.NameShort != "" {
@@ -172,18 +213,36 @@ func (item vehicleItem) toVehicle(id, nation string) types.Vehicle {
 	}
 
 	return types.Vehicle{
-		Key: key,
-		ID:  id,
+		Key:     key,
+		NameKey: item.Name,
+		ID:      id,
 
 		Tier:        item.level,
 		Class:       item.class(),
 		Nation:      nation,
-		Premium:     item.premium,
+		Premium:     item.price.Currency == "gold",
+		Price:       item.price,
 		SuperTest:   slices.Contains(item.environments, "supertest") && !slices.Contains(item.environments, "production"),
 		Collectible: slices.Contains(item.tags, "collectible"),
+
+		Tags: append([]string{}, item.tags...),
+		Role: item.vehicleRole,
 	}
 }
 
+// stringKeys returns localization keys of a vehicle, a vehicle without a short name uses the full name.
+// Role names are read from role_<role> strings.
+func (item vehicleItem) stringKeys() vehicleStringKeys {
+	keys := vehicleStringKeys{name: item.Name, shortName: item.NameShort, description: item.Description}
+	if keys.shortName == "" {
+		keys.shortName = item.Name
+	}
+	if item.vehicleRole != "" {
+		keys.role = vehicleRolePrefix + string(item.vehicleRole)
+	}
+	return keys
+}
+
 func (p *vehicleItemsParser) Exclusive() bool {
 	return true
 }
@@ -198,14 +257,14 @@ func (p *vehicleItemsParser) Parse(path string, r io.Reader) error {
 	}
 
 	nation := filepath.Base(filepath.Dir(path))
-	if _, ok := nationIDs[nation]; !ok {
-		return errors.New("invalid nation " + nation)
+	if _, ok := globalid.NationID(nation); !ok {
+		return errors.Errorf("nation %s is not in the nations registry", nation)
 	}
 
-	// Parse raw XML to determine which vehicles have gold prices (premium indicator).
-	// The mxj library strips the <gold/> sub-element from <price>, so we use
-	// encoding/xml to detect it from the raw bytes.
-	premiumVehicles := parseGoldPriceVehicles(raw)
+	prices, err := parseVehiclePrices(raw)
+	if err != nil {
+		return err
+	}
 
 	p.lock.Lock()
 	defer p.lock.Unlock()
@@ -213,42 +272,50 @@ func (p *vehicleItemsParser) Parse(path string, r io.Reader) error {
 	for name, item := range data {
 		item.id, _ = strconv.Atoi(item.ID)
 		item.level, _ = strconv.Atoi(item.Level)
-		item.tags = strings.Split(item.Tags, " ")
+		item.tags = strings.Fields(item.Tags)
 		item.environments = strings.Split(item.Environments, " ")
-		item.premium = premiumVehicles[name]
+		item.price = prices[name]
+		item.vehicleRole, err = item.role()
+		if err != nil {
+			return errors.Wrap(err, "invalid tags for vehicle "+name)
+		}
 
-		id := fmt.Sprint(toGlobalID(nation, item.id))
-		vehicle := item.toVehicle(id, nation)
+		id, err := globalid.Encode(nation, item.id)
+		if err != nil {
+			return errors.Wrap(err, "invalid id for vehicle "+name)
+		}
+		vehicle := item.toVehicle(fmt.Sprint(id), nation)
 		p.vehicles[vehicle.ID] = vehicle
+		p.vehicleIDs[nation+"/"+name] = vehicle.ID
+		p.vehicleKeys[vehicle.ID] = item.stringKeys()
 	}
 
 	return nil
 }
 
+// vehicleStringsParser resolves full and short names, descriptions and role names of vehicles from merged json Strings files
 type vehicleStringsParser struct {
-	vehicleNames map[string]map[language.Tag]string
-	vehicles     map[string]types.Vehicle
-	lock         *sync.Mutex
+	vehicleNames        map[string]map[language.Tag]string
+	vehicleShortNames   map[string]map[language.Tag]string
+	vehicleDescriptions map[string]map[language.Tag]string
+	vehicleRoleNames    map[string]map[language.Tag]string
+	vehicleKeys         map[string]vehicleStringKeys
+	lock                *sync.Mutex
 }
 
+func (p *vehicleStringsParser) DependsOn() []string {
+	return []string{"vehicles"}
+}
 func (p *vehicleStringsParser) Exclusive() bool {
 	return true
 }
 
-var jsonStringsRegex = regexp.MustCompile(".*/Strings/.*.json")
-
 func (p *vehicleStringsParser) Match(path string) bool {
 	return jsonStringsRegex.MatchString(path)
 }
 
 func (p *vehicleStringsParser) Parse(path string, r io.Reader) error {
-	lang := strings.Split(filepath.Base(path), ".")[0]
-	locale, err := language.Parse(lang)
-	if err != nil {
-		return errors.Wrap(err, "failed to get locale from a filename")
-	}
-
-	data, err := decodeJSON[map[string]string](r)
+	locale, data, err := decodeJSONStrings(path, r)
 	if err != nil {
 		return err
 	}
@@ -256,17 +323,12 @@ func (p *vehicleStringsParser) Parse(path string, r io.Reader) error {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
-	for key, vehicle := range p.vehicles {
-		names := p.vehicleNames[key]
-		if names == nil {
-			names = make(map[language.Tag]string)
-		}
-		if localized, ok := data[vehicle.Key]; ok {
-			names[locale] = localized
-		}
-		p.vehicleNames[key] = names
+	for id, keys := range p.vehicleKeys {
+		addLocalized(p.vehicleNames, id, locale, data, keys.name)
+		addLocalized(p.vehicleShortNames, id, locale, data, keys.shortName)
+		addLocalized(p.vehicleDescriptions, id, locale, data, keys.description)
+		addLocalized(p.vehicleRoleNames, id, locale, data, keys.role)
 	}
 
 	return nil
 }
-
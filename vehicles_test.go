@@ -0,0 +1,110 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/cufee/aftermath-assets/types"
+	"github.com/matryer/is"
+	"golang.org/x/text/language"
+)
+
+func TestVehiclePrices(t *testing.T) {
+	is := is.New(t)
+
+	fsys := fstest.MapFS{
+		"XML/item_defs/vehicles/ussr/list.xml": &fstest.MapFile{Data: []byte(`<root>
+	<T-34><id>0</id><level>5</level><price>1000</price></T-34>
+	<T-34-85><id>1</id><level>6</level><price>920000</price></T-34-85>
+	<T-34-85M><id>2</id><level>6</level><price>5000<gold/></price></T-34-85M>
+	<T-34-85_Victory><id>3</id><level>6</level><price>3000<eventCoin/></price><notInShop>true</notInShop></T-34-85_Victory>
+</root>`)},
+		"XML/item_defs/vehicles/ussr/T-34.xml": &fstest.MapFile{Data: []byte(`<root>
+	<turrets0>
+		<T-34_turret_2>
+			<guns><_76mm_F-34><unlocks><vehicle>T-34-85<cost>20500</cost></vehicle></unlocks></_76mm_F-34></guns>
+			<unlocks><vehicle>ussr:T-34-85<cost>21000</cost></vehicle><gun>_85mm_D-5T<cost>9000</cost></gun></unlocks>
+		</T-34_turret_2>
+	</turrets0>
+</root>`)},
+	}
+
+	vehicles := readTestAsset[map[string]types.Vehicle](t, parseTestAssets(t, fsys, "vehicles"), "vehicles.json")
+
+	is.Equal(vehicles["1"].Price, types.VehiclePrice{Amount: 1000, Currency: "credits"})
+	is.Equal(vehicles["257"].Price, types.VehiclePrice{Amount: 920000, Currency: "credits", ResearchXP: 20500})
+	is.Equal(vehicles["513"].Price, types.VehiclePrice{Amount: 5000, Currency: "gold"})
+	is.True(vehicles["513"].Premium)
+	is.Equal(vehicles["769"].Price, types.VehiclePrice{Amount: 3000, Currency: "eventCoin", NotInShop: true})
+	is.True(!vehicles["769"].Premium)
+}
+
+func TestVehicleStrings(t *testing.T) {
+	is := is.New(t)
+
+	fsys := fstest.MapFS{
+		"XML/item_defs/vehicles/germany/list.xml": &fstest.MapFile{Data: []byte(`<root>
+	<VK2801>
+		<id>39</id>
+		<userString>#germany_vehicles:VK2801</userString>
+		<shortUserString>#germany_vehicles:VK2801_short</shortUserString>
+		<description>#germany_vehicles:VK2801_descr</description>
+	</VK2801>
+	<Pz35t><id>1</id><userString>#germany_vehicles:Pz35t</userString></Pz35t>
+</root>`)},
+		"Strings/en.json": &fstest.MapFile{Data: []byte(`{
+	"#germany_vehicles:VK2801": "VK 28.01 mit 10,5 cm L/28",
+	"#germany_vehicles:VK2801_short": "VK 28.01",
+	"#germany_vehicles:VK2801_descr": "A light tank project.",
+	"#germany_vehicles:Pz35t": "Pz.Kpfw. 35 (t)"
+}`)},
+	}
+
+	vehicles := readTestAsset[map[string]types.Vehicle](t, parseTestAssets(t, fsys, "vehicles"), "vehicles.json")
+
+	vk := vehicles["10001"]
+	is.Equal(vk.Key, "#germany_vehicles:VK2801_short")
+	is.Equal(vk.NameKey, "#germany_vehicles:VK2801")
+	is.Equal(vk.LocalizedNames[language.English], "VK 28.01 mit 10,5 cm L/28")
+	is.Equal(vk.LocalizedShortNames[language.English], "VK 28.01")
+	is.Equal(vk.LocalizedDescriptions[language.English], "A light tank project.")
+
+	pz := vehicles["273"]
+	is.Equal(pz.Key, "#germany_vehicles:Pz35t")
+	is.Equal(pz.LocalizedShortNames[language.English], "Pz.Kpfw. 35 (t)")
+	is.Equal(len(pz.LocalizedDescriptions), 0)
+}
+
+func TestVehicleTags(t *testing.T) {
+	is := is.New(t)
+
+	fsys := fstest.MapFS{
+		"XML/item_defs/vehicles/ussr/list.xml": &fstest.MapFile{Data: []byte(`<root>
+	<T-34><id>0</id><tags>mediumTank role_MT_universal</tags></T-34>
+	<T-34-85><id>1</id><tags>mediumTank  collectible</tags></T-34-85>
+	<T-34_bare><id>3</id></T-34_bare>
+</root>`)},
+		"Strings/en.json": &fstest.MapFile{Data: []byte(`{"role_MT_universal": "Universal", "role_MT_universal/descr": "Versatile"}`)},
+	}
+
+	vehicles := readTestAsset[map[string]types.Vehicle](t, parseTestAssets(t, fsys, "vehicles"), "vehicles.json")
+
+	is.Equal(vehicles["1"].Tags, []string{"mediumTank", "role_MT_universal"})
+	is.Equal(vehicles["1"].Role, types.RoleMediumUniversal)
+	is.Equal(vehicles["1"].LocalizedRoleNames, map[language.Tag]string{language.English: "Universal"})
+	is.Equal(vehicles["1"].Class, "mediumTank")
+	is.Equal(vehicles["257"].Tags, []string{"mediumTank", "collectible"})
+	is.True(vehicles["257"].Collectible)
+	is.Equal(vehicles["769"].Tags, []string{})
+	is.Equal(vehicles["769"].Role, types.VehicleRole(""))
+	is.Equal(len(vehicles["769"].LocalizedRoleNames), 0)
+}
+
+func TestVehicleUnknownRole(t *testing.T) {
+	is := is.New(t)
+
+	parser := newVehiclesParser().Items()
+	err := parser.Parse("XML/item_defs/vehicles/ussr/list.xml", strings.NewReader(`<root><T-34><id>0</id><tags>mediumTank role_MT_unknown</tags></T-34></root>`))
+	is.True(err != nil)
+}
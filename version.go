@@ -11,6 +11,13 @@ import (
 	"github.com/pkg/errors"
 )
 
+func init() {
+	registerAsset("metadata", func() asset {
+		version := newVersionParser()
+		return asset{File: "metadata.json", Parsers: map[string]parseFunc{"metadata": version}, Exporter: version}
+	})
+}
+
 type versionParser struct {
 	Tag         string `json:"tag"`
 	Arch        string `json:"arch"`
@@ -47,18 +54,18 @@ func (p *versionParser) Match(path string) bool {
 	return strings.HasSuffix(path, "version.txt")
 }
 
-func (p *versionParser) Export(filePath string) error {
+func (p *versionParser) Export(filePath string) (int, error) {
 	err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
 	if err != nil {
-		return errors.Wrap(err, "failed to create path")
+		return 0, errors.Wrap(err, "failed to create path")
 	}
 
 	f, err := os.Create(filePath)
 	if err != nil {
-		return errors.Wrap(err, "failed to create file")
+		return 0, errors.Wrap(err, "failed to create file")
 	}
 
 	e := json.NewEncoder(f)
 	e.SetIndent("", "  ")
-	return e.Encode(p)
+	return 1, e.Encode(p)
 }